@@ -0,0 +1,74 @@
+package webdriver
+
+import "fmt"
+
+// angularStableScript returns [stable, what is still going on]. For AngularJS
+// it relies on $browser.notifyWhenNoOutstandingRequests calling back straight
+// away when no $http request or $timeout is outstanding, which it has done
+// since 1.0. The AngularJS root is the element matching arguments[0] if
+// given, else the first one marked by ng-app or debug info. Pages without
+// Angular count as stable
+const angularStableScript = `var w = window, sel = arguments[0];
+if (w.getAllAngularTestabilities) {
+  var ts = w.getAllAngularTestabilities(), busy = 0;
+  for (var i = 0; i < ts.length; i++) {
+    if (!ts[i].isStable()) { busy++; }
+  }
+  return [busy == 0, busy + " of " + ts.length + " Angular testabilities not stable"];
+}
+if (!w.angular) {
+  return [true, "no Angular on the page"];
+}
+var root = document.querySelector(sel || "[ng-app], [data-ng-app], [x-ng-app], .ng-scope");
+if (!root) {
+  return [false, sel ? "no AngularJS root " + sel : "no ng-app or .ng-scope element, see AngularStableIn"];
+}
+var injector = w.angular.element(root).injector();
+if (!injector) {
+  return [false, "AngularJS not bootstrapped yet"];
+}
+var pending = injector.get("$http").pendingRequests.length;
+if (pending > 0) {
+  return [false, pending + " pending $http requests"];
+}
+var idle = false;
+injector.get("$browser").notifyWhenNoOutstandingRequests(function() { idle = true; });
+return [idle, idle ? "AngularJS idle" : "outstanding $timeout or $http"];`
+
+// AngularStable is done once Angular has nothing left to do. For AngularJS
+// that is no pending $http request, no outstanding $timeout and the digest
+// finished, for Angular 2+ every testability being stable. A page without
+// Angular is stable straight away.
+//
+// There is no separate digest check: a digest runs synchronously, so the
+// check, a script of its own, never runs in the middle of one, and a digest
+// still to come is scheduled through $browser.defer, which counts as
+// outstanding until notifyWhenNoOutstandingRequests calls back.
+//
+// WithAngularSync waits for it before every find, so before every click too
+func AngularStable() Condition {
+  return AngularStableIn("")
+}
+
+// AngularStableIn is AngularStable for an AngularJS app rooted at the
+// element matching rootSelector, like Protractor's rootSelector. Apps
+// started with angular.bootstrap and debug info disabled need it, nothing
+// on the page marks their root
+func AngularStableIn(rootSelector string) Condition {
+  desc := "Angular to be stable"
+  if rootSelector != "" {
+    desc = "Angular app in " + rootSelector + " to be stable"
+  }
+  return condition{desc, func(s *Session) (bool, string, error) {
+    var result []interface{}
+    if err := s.script(angularStableScript, []interface{}{rootSelector}, &result); err != nil {
+      return false, "", err
+    }
+    if len(result) != 2 {
+      return false, "", fmt.Errorf("Unexpected result from the Angular check: %v", result)
+    }
+    stable, _ := result[0].(bool)
+    observed, _ := result[1].(string)
+    return stable, observed, nil
+  }}
+}
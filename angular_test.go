@@ -0,0 +1,91 @@
+package webdriver
+
+import (
+  "code.grantmurray.com/webdriver/fakewd"
+  "context"
+  "errors"
+  "testing"
+  "time"
+)
+
+// busyAngular makes the Angular check report busy for the first n calls
+func busyAngular(srv *fakewd.Server, n int) *int {
+  calls := 0
+  srv.HandleScript("getAllAngularTestabilities", func(b *fakewd.Browser, args []interface{}) (interface{}, error) {
+    calls++
+    if calls <= n {
+      return []interface{}{false, "2 pending $http requests"}, nil
+    }
+    return []interface{}{true, "AngularJS idle"}, nil
+  })
+  return &calls
+}
+
+func Test_AngularStable(t *testing.T) {
+  s, srv := newFakeSession(t)
+  s.PollInterval = time.Millisecond
+  calls := busyAngular(srv, 2)
+
+  if err := s.WaitUntil(context.Background(), AngularStable()); err != nil || *calls != 3 {
+    t.Errorf("Expected Angular to settle on the third check, got %v after %d", err, *calls)
+  }
+
+  busyAngular(srv, 1000)
+  ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+  defer cancel()
+  var te *TimeoutError
+  if err := s.WaitUntil(ctx, AngularStable()); !errors.As(err, &te) || te.Observed != "2 pending $http requests" {
+    t.Errorf("Expected a timeout saying what is pending, got %v", err)
+  }
+}
+
+func Test_AngularStableIn(t *testing.T) {
+  s, srv := newFakeSession(t)
+  var root interface{}
+  srv.HandleScript("getAllAngularTestabilities", func(b *fakewd.Browser, args []interface{}) (interface{}, error) {
+    root = args[0]
+    return []interface{}{true, "AngularJS idle"}, nil
+  })
+
+  if err := s.WaitUntil(context.Background(), AngularStableIn("#app")); err != nil || root != "#app" {
+    t.Errorf("Expected the check to look for the root in #app, got %v (%v)", root, err)
+  }
+  if desc := AngularStableIn("#app").String(); desc != "Angular app in #app to be stable" {
+    t.Errorf("Expected the root selector in the description, got %q", desc)
+  }
+  c, err := NewConfig(WithAngularSyncIn("body > main"))
+  if err != nil {
+    t.Fatal(err)
+  }
+  if err = s.WaitUntil(context.Background(), c.Sync); err != nil || root != "body > main" {
+    t.Errorf("Expected WithAngularSyncIn to pass its root on, got %v (%v)", root, err)
+  }
+}
+
+func Test_Sync(t *testing.T) {
+  s, srv := newFakeSession(t)
+  loginPage(srv)
+  s.Drv.Get(loginURL)
+  s.PollInterval = time.Millisecond
+  s.Sync = AngularStable()
+  calls := busyAngular(srv, 2)
+
+  if err := s.NewElement(Name("LoginButton")).Click(); err != nil {
+    t.Fatalf("Cannot click: %s", err)
+  }
+  if *calls != 3 {
+    t.Errorf("Expected the click to wait for Angular, it was checked %d times", *calls)
+  }
+
+  // conditions polled by WaitUntil find without syncing
+  *calls = 0
+  if err := s.WaitUntil(context.Background(), ElementAppears(Name("LoginButton"))); err != nil || *calls != 0 {
+    t.Errorf("Expected no Angular checks inside WaitUntil, got %d (%v)", *calls, err)
+  }
+
+  busyAngular(srv, 1000)
+  s.WaitTimeout = 20 * time.Millisecond
+  if _, err := s.Find(Name("LoginButton")); !errors.Is(err, ErrTimeout) {
+    t.Errorf("Expected the find to time out waiting for Angular, got %v", err)
+  }
+}
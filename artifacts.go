@@ -0,0 +1,126 @@
+package webdriver
+
+import (
+  "encoding/json"
+  "errors"
+  "fmt"
+  "io/ioutil"
+  "os"
+  "path/filepath"
+  "strings"
+  "testing"
+)
+
+// CaptureOnFailure arranges for CaptureArtifacts to run when t finishes, if
+// it failed. Call it first thing in a test, before anything that can fail:
+//
+//   func Test_Profile_Success(t *testing.T) {
+//     webdriver.CaptureOnFailure(t)
+//     ...
+//
+// The artifacts go to a directory named after t.Name() under Config.ArtifactDir
+// (-webdriver.artifacts) and their paths are logged on t. console.log only
+// holds what was collected after the call, not the earlier tests' console
+func (s *Session) CaptureOnFailure(t testing.TB) {
+  start := len(s.console)
+  t.Cleanup(func() {
+    if !t.Failed() {
+      return
+    }
+    dir := filepath.Join(s.artifactDir(), artifactName(t.Name()))
+    paths, err := s.captureArtifacts(dir, start)
+    for _, p := range paths {
+      t.Logf("artifact: %s", p)
+    }
+    if err != nil {
+      t.Logf("Some artifacts could not be captured: %s", err)
+    }
+  })
+}
+
+func (s *Session) artifactDir() string {
+  if s.Config.ArtifactDir != "" {
+    return s.Config.ArtifactDir
+  }
+  return filepath.Join(os.TempDir(), "webdriver-artifacts")
+}
+
+// artifactName makes a test name usable as a directory name, subtests
+// (Test_Login_table/bad_password) are flattened rather than nested
+func artifactName(name string) string {
+  return strings.Map(func(r rune) rune {
+    if r == '/' || r == '\\' || r == ':' || r == '*' || r == '?' || r == '"' || r == '<' || r == '>' || r == '|' || r < ' ' {
+      return '_'
+    }
+    return r
+  }, name)
+}
+
+// CaptureArtifacts saves what the browser shows now into dir, overwriting what
+// an earlier run left there: screenshot.png, source.html, url.txt,
+// cookies.json and console.log. It carries on past anything that cannot be
+// captured and returns the paths it did write
+func (s *Session) CaptureArtifacts(dir string) (paths []string, err error) {
+  return s.captureArtifacts(dir, 0)
+}
+
+// captureArtifacts writes the console entries from the console'th on
+func (s *Session) captureArtifacts(dir string, console int) (paths []string, err error) {
+  if err = os.MkdirAll(dir, 0755); err != nil {
+    return nil, err
+  }
+
+  var errs []string
+  save := func(name string, fetch func() ([]byte, error)) {
+    data, err := fetch()
+    if err == nil {
+      p := filepath.Join(dir, name)
+      if err = ioutil.WriteFile(p, data, 0644); err == nil {
+        paths = append(paths, p)
+        return
+      }
+    }
+    errs = append(errs, fmt.Sprintf("%s: %s", name, err))
+  }
+
+  save("screenshot.png", s.Drv.Screenshot)
+  save("source.html", func() ([]byte, error) {
+    src, err := s.Drv.PageSource()
+    return []byte(src), err
+  })
+  save("url.txt", func() ([]byte, error) {
+    url, err := s.Drv.CurrentURL()
+    return []byte(url + "\n"), err
+  })
+  save("cookies.json", func() ([]byte, error) {
+    cookies, err := s.Cookies()
+    if err != nil {
+      return nil, err
+    }
+    return json.MarshalIndent(cookies, "", "  ")
+  })
+  save("console.log", func() ([]byte, error) {
+    entries, err := s.Console()
+    if err != nil {
+      return nil, err
+    }
+    if console > len(entries) {
+      console = len(entries)
+    }
+    var b strings.Builder
+    for _, e := range entries[console:] {
+      b.WriteString(e.String() + "\n")
+    }
+    return []byte(b.String()), nil
+  })
+
+  if len(errs) > 0 {
+    return paths, errors.New(strings.Join(errs, "; "))
+  }
+  return paths, nil
+}
+
+// CaptureOnFailure captures artifacts from the default session if t fails
+func CaptureOnFailure(t testing.TB) {
+  DefaultSession().CaptureOnFailure(t)
+}
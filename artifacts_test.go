@@ -0,0 +1,91 @@
+package webdriver
+
+import (
+  "code.grantmurray.com/webdriver/fakewd"
+  "encoding/json"
+  "fmt"
+  "io/ioutil"
+  "path/filepath"
+  "strings"
+  "testing"
+)
+
+// recordingTB stands in for the testing.T of a test that may fail
+type recordingTB struct {
+  testing.TB
+  name     string
+  failed   bool
+  cleanups []func()
+  logs     []string
+}
+
+func (r *recordingTB) Name() string     { return r.name }
+func (r *recordingTB) Failed() bool     { return r.failed }
+func (r *recordingTB) Cleanup(f func()) { r.cleanups = append(r.cleanups, f) }
+func (r *recordingTB) Logf(f string, args ...interface{}) {
+  r.logs = append(r.logs, fmt.Sprintf(f, args...))
+}
+
+func (r *recordingTB) Errorf(f string, args ...interface{}) {
+  r.failed = true
+  r.Logf(f, args...)
+}
+
+func (r *recordingTB) finish() {
+  for i := len(r.cleanups) - 1; i >= 0; i-- {
+    r.cleanups[i]()
+  }
+}
+
+func Test_CaptureOnFailure(t *testing.T) {
+  s, srv := newFakeSession(t)
+  loginPage(srv)
+  s.Config.ArtifactDir = t.TempDir()
+  s.Drv.Get(loginURL)
+
+  b := srv.Sessions()[0]
+  b.SetCookie(fakewd.Cookie{Name: "SessionToken", Value: "abc"})
+  b.Console("INFO", "logged in an earlier test")
+  s.Console()
+
+  passed := &recordingTB{TB: t, name: "Test_Login_table/good"}
+  s.CaptureOnFailure(passed)
+  passed.finish()
+  if len(passed.logs) != 0 {
+    t.Errorf("Expected nothing captured for a passing test, got %q", passed.logs)
+  }
+
+  failed := &recordingTB{TB: t, name: "Test_Login_table/bad", failed: true}
+  s.CaptureOnFailure(failed)
+  b.Console("SEVERE", "Uncaught TypeError: x is undefined")
+  failed.finish()
+
+  dir := filepath.Join(s.Config.ArtifactDir, "Test_Login_table_bad")
+  read := func(name string) string {
+    data, err := ioutil.ReadFile(filepath.Join(dir, name))
+    if err != nil {
+      t.Errorf("Expected %s to be captured: %s", name, err)
+    }
+    return string(data)
+  }
+
+  if url := read("url.txt"); url != loginURL+"\n" {
+    t.Errorf("Expected url.txt to hold %s, got %q", loginURL, url)
+  }
+  if src := read("source.html"); src != srv.Page(loginURL).Source {
+    t.Errorf("Expected source.html to hold the page source, got %q", src)
+  }
+  if png := read("screenshot.png"); !strings.HasPrefix(png, "\x89PNG") {
+    t.Errorf("Expected screenshot.png to be a PNG")
+  }
+  var cookies []map[string]interface{}
+  if err := json.Unmarshal([]byte(read("cookies.json")), &cookies); err != nil || len(cookies) != 1 || cookies[0]["value"] != "abc" {
+    t.Errorf("Expected the session cookie in cookies.json, got %v (%v)", cookies, err)
+  }
+  if log := read("console.log"); !strings.Contains(log, "SEVERE  Uncaught TypeError") || strings.Contains(log, "earlier test") {
+    t.Errorf("Expected only this test's console error in console.log, got %q", log)
+  }
+  if len(failed.logs) != 5 {
+    t.Errorf("Expected the 5 artifact paths to be logged, got %q", failed.logs)
+  }
+}
@@ -0,0 +1,128 @@
+package webdriver
+
+import (
+  "context"
+  "errors"
+  "fmt"
+  "strings"
+  "time"
+)
+
+// BusyMarker is how an application shows it is busy, typically while it
+// talks to its server: an element that is present, an attribute with a
+// certain value, or a JavaScript expression that is true. With a marker set
+// (see WithBusyMarker) the session waits for it to clear after opening a
+// page and after clicking, typing into or clearing an Element:
+//
+//   webdriver.WithBusyMarker(webdriver.BusyMarker{Locator: webdriver.CSS("div.selenium-flag")})
+//   webdriver.WithBusyMarker(webdriver.BusyMarker{Locator: webdriver.CSS("body"), Attribute: "aria-busy", Value: "true"})
+//   webdriver.WithBusyMarker(webdriver.BusyMarker{Script: "window.pendingSaves > 0"})
+type BusyMarker struct {
+  Locator   Locator // busy while an element matches
+  Attribute string  // with Locator, busy only while an element's Attribute is Value, or is there at all if Value is ""
+  Value     string
+  Script    string // instead of Locator, busy while this JavaScript expression is true
+
+  Timeout time.Duration // how long the marker may stay, 0 for the session's WaitTimeout
+  Appear  time.Duration // how long after an interaction the marker may take to show up, 0 to look straight away
+}
+
+func (m BusyMarker) String() string {
+  switch {
+  case m.Script != "":
+    return "busy marker " + m.Script
+  case m.Attribute != "" && m.Value != "":
+    return fmt.Sprintf("busy marker %s[%s=%q]", m.Locator, m.Attribute, m.Value)
+  case m.Attribute != "":
+    return fmt.Sprintf("busy marker %s[%s]", m.Locator, m.Attribute)
+  }
+  return "busy marker " + m.Locator.String()
+}
+
+// busy checks the marker once. It uses the driver directly, finds made for
+// the marker must not sync (see Session.Sync)
+func (m BusyMarker) busy(s *Session) (bool, string, error) {
+  if m.Script != "" {
+    var busy bool
+    if err := s.script("return !!("+m.Script+");", nil, &busy); err != nil {
+      return false, "", err
+    }
+    return busy, fmt.Sprintf("%s is %t", m.Script, busy), nil
+  }
+
+  es, err := s.Drv.FindElements(m.Locator.By, m.Locator.Value)
+  if err = s.wrap("find all", &m.Locator, err); err != nil {
+    if transient(err) {
+      return false, err.Error(), nil
+    }
+    return false, "", err
+  }
+  var seen []string
+  for _, e := range es {
+    if m.Attribute == "" {
+      text, _ := e.Text()
+      seen = append(seen, fmt.Sprintf("element with text %q", text))
+      continue
+    }
+    value, err := e.GetAttribute(m.Attribute)
+    if err == nil && (m.Value == "" || value == m.Value) {
+      seen = append(seen, fmt.Sprintf("element with %s=%q", m.Attribute, value))
+    }
+  }
+  if len(seen) == 0 {
+    return false, "marker gone", nil
+  }
+  return true, strings.Join(seen, ", "), nil
+}
+
+// Cleared is done once the marker no longer shows
+func (m BusyMarker) Cleared() Condition {
+  return condition{m.String() + " to clear", func(s *Session) (bool, string, error) {
+    busy, observed, err := m.busy(s)
+    return !busy && err == nil, observed, err
+  }}
+}
+
+// WaitNotBusy waits for the session's BusyMarker to clear, within the
+// marker's own Timeout. It returns straight away when there is no marker
+func (s *Session) WaitNotBusy() error {
+  if s.Busy == nil {
+    return nil
+  }
+  m := *s.Busy
+  timeout := m.Timeout
+  if timeout <= 0 {
+    timeout = s.WaitTimeout
+  }
+  ctx, cancel := context.WithTimeout(context.Background(), timeout)
+  defer cancel()
+
+  err := s.WaitUntil(ctx, m.Cleared())
+  var te *TimeoutError
+  if errors.As(err, &te) {
+    url, _ := s.Drv.CurrentURL()
+    return &Error{Op: "wait for the application", URL: url, Kind: ErrTimeout, Err: fmt.Errorf("still busy: %w", err)}
+  }
+  return err
+}
+
+// settle waits for the marker after an interaction, giving it m.Appear to show up
+func (s *Session) settle() error {
+  if s.Busy == nil {
+    return nil
+  }
+  if s.Busy.Appear > 0 {
+    ctx, cancel := context.WithTimeout(context.Background(), s.Busy.Appear)
+    defer cancel()
+    appeared := condition{s.Busy.String() + " to appear", s.Busy.busy}
+    if err := s.WaitUntil(ctx, appeared); err != nil && !errors.Is(err, context.DeadlineExceeded) {
+      return err
+    }
+  }
+  return s.WaitNotBusy()
+}
+
+// WaitNotBusy waits for the default session's BusyMarker to clear
+func WaitNotBusy() error {
+  return DefaultSession().WaitNotBusy()
+}
@@ -0,0 +1,64 @@
+package webdriver
+
+import (
+  "code.grantmurray.com/webdriver/fakewd"
+  "errors"
+  "strings"
+  "testing"
+  "time"
+)
+
+func Test_BusyMarker_element(t *testing.T) {
+  s, srv := newFakeSession(t)
+  page := loginPage(srv)
+  s.Drv.Get(loginURL)
+  s.PollInterval = time.Millisecond
+  s.Busy = &BusyMarker{Locator: CSS("div.selenium-flag"), Timeout: 20 * time.Millisecond}
+
+  var flag *fakewd.Element
+  page.Find(`[name="LoginButton"]`).OnClick = func(b *fakewd.Browser) error {
+    flag = fakewd.E("div", "class", "selenium-flag").WithText("Logging in")
+    page.Add(flag)
+    return nil
+  }
+  err := s.NewElement(Name("LoginButton")).Click()
+  if !errors.Is(err, ErrTimeout) || !strings.Contains(err.Error(), `still busy`) || !strings.Contains(err.Error(), `text "Logging in"`) {
+    t.Errorf("Expected a timeout describing the marker, got %v", err)
+  }
+
+  flag.Remove()
+  if err = s.WaitNotBusy(); err != nil {
+    t.Errorf("Expected the marker to be gone, got %v", err)
+  }
+  if err = s.NewElement(Name("UserIdentifier")).Fill("george"); err != nil {
+    t.Errorf("Expected typing to pass without a marker, got %v", err)
+  }
+}
+
+func Test_BusyMarker_attribute_script(t *testing.T) {
+  s, srv := newFakeSession(t)
+  page := loginPage(srv)
+  s.Drv.Get(loginURL)
+  s.PollInterval = time.Millisecond
+  form := page.Find(`form`)
+
+  s.Busy = &BusyMarker{Locator: Name("loginForm"), Attribute: "aria-busy", Value: "true", Timeout: 20 * time.Millisecond}
+  form.Attrs["aria-busy"] = "false"
+  if err := s.WaitNotBusy(); err != nil {
+    t.Errorf("Expected aria-busy=false not to count, got %v", err)
+  }
+  form.Attrs["aria-busy"] = "true"
+  if err := s.WaitNotBusy(); !errors.Is(err, ErrTimeout) {
+    t.Errorf("Expected aria-busy=true to time out, got %v", err)
+  }
+
+  checks := 0
+  srv.HandleScript("window.pendingSaves", func(b *fakewd.Browser, args []interface{}) (interface{}, error) {
+    checks++
+    return checks < 3, nil
+  })
+  s.Busy = &BusyMarker{Script: "window.pendingSaves > 0"}
+  if err := s.WaitNotBusy(); err != nil || checks != 3 {
+    t.Errorf("Expected the script to clear on the third check, got %v after %d", err, checks)
+  }
+}
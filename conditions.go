@@ -0,0 +1,173 @@
+package webdriver
+
+import (
+  "errors"
+  "fmt"
+  "github.com/sourcegraph/go-selenium"
+  "strings"
+)
+
+// condition is the Condition behind all the constructors in this file
+type condition struct {
+  desc  string
+  check func(s *Session) (bool, string, error)
+}
+
+func (c condition) Check(s *Session) (bool, string, error) {
+  return c.check(s)
+}
+
+func (c condition) String() string {
+  return c.desc
+}
+
+// Named gives fn a description for use in timeout messages
+func Named(desc string, fn ConditionFunc) Condition {
+  return condition{desc, fn}
+}
+
+// transient reports whether a driver error just means "not yet", these are
+// observed rather than ending the wait
+func transient(err error) bool {
+  return errors.Is(err, ErrNoSuchElement) || errors.Is(err, ErrStaleElement) || errors.Is(err, ErrNoSuchAttribute)
+}
+
+// findOne looks up loc and turns transient failures into an observation
+func findOne(s *Session, loc Locator) (e selenium.WebElement, observed string, err error) {
+  e, err = s.Find(loc)
+  if err != nil {
+    if transient(err) {
+      return nil, err.Error(), nil
+    }
+    return nil, "", err
+  }
+  return e, "", nil
+}
+
+// ElementAppears is done once an element matches loc
+func ElementAppears(loc Locator) Condition {
+  return condition{"element " + loc.String() + " to appear", func(s *Session) (bool, string, error) {
+    e, observed, err := findOne(s, loc)
+    return e != nil, observed, err
+  }}
+}
+
+// ElementVanishes is done once no element matches loc
+func ElementVanishes(loc Locator) Condition {
+  return condition{"element " + loc.String() + " to vanish", func(s *Session) (bool, string, error) {
+    e, _, err := findOne(s, loc)
+    if err != nil {
+      return false, "", err
+    }
+    return e == nil, "element still present", nil
+  }}
+}
+
+// URLIs is done once the current url is one of urls
+func URLIs(urls ...string) Condition {
+  return condition{"url to be " + strings.Join(urls, " or "), func(s *Session) (bool, string, error) {
+    cur, err := s.Drv.CurrentURL()
+    if err != nil {
+      return false, "", err
+    }
+    for _, u := range urls {
+      if cur == u {
+        return true, cur, nil
+      }
+    }
+    return false, "url " + cur, nil
+  }}
+}
+
+// TextEquals is done once the element matching loc has exactly the text want
+func TextEquals(loc Locator, want string) Condition {
+  return condition{fmt.Sprintf("text of %s to be %q", loc, want), func(s *Session) (bool, string, error) {
+    e, observed, err := findOne(s, loc)
+    if e == nil {
+      return false, observed, err
+    }
+    got, err := e.Text()
+    if err = s.wrap("retrieve text of", &loc, err); err != nil {
+      if transient(err) {
+        return false, err.Error(), nil
+      }
+      return false, "", err
+    }
+    return got == want, fmt.Sprintf("text %q", got), nil
+  }}
+}
+
+// AttributeIs is done once attribute name of the element matching loc equals want
+func AttributeIs(loc Locator, name, want string) Condition {
+  return condition{fmt.Sprintf("%s of %s to be %q", name, loc, want), func(s *Session) (bool, string, error) {
+    e, observed, err := findOne(s, loc)
+    if e == nil {
+      return false, observed, err
+    }
+    got, err := e.GetAttribute(name)
+    switch err = s.wrap("retrieve "+name+" of", &loc, err); {
+    case errors.Is(err, ErrNoSuchAttribute):
+      return false, "attribute absent", nil
+    case transient(err):
+      return false, err.Error(), nil
+    case err != nil:
+      return false, "", err
+    }
+    return got == want, fmt.Sprintf("%s %q", name, got), nil
+  }}
+}
+
+// ElementCount is done once exactly n elements match loc
+func ElementCount(loc Locator, n int) Condition {
+  return condition{fmt.Sprintf("%d elements matching %s", n, loc), func(s *Session) (bool, string, error) {
+    es, err := s.FindAll(loc)
+    if err != nil {
+      return false, "", err
+    }
+    return len(es) == n, fmt.Sprintf("%d elements", len(es)), nil
+  }}
+}
+
+// And is done when all of conds are done, they are checked in order
+func And(conds ...Condition) Condition {
+  return condition{describe(conds, " and "), func(s *Session) (bool, string, error) {
+    for _, c := range conds {
+      done, observed, err := c.Check(s)
+      if err != nil || !done {
+        return false, fmt.Sprintf("%s: %s", c, observed), err
+      }
+    }
+    return true, "", nil
+  }}
+}
+
+// Or is done as soon as one of conds is done
+func Or(conds ...Condition) Condition {
+  return condition{describe(conds, " or "), func(s *Session) (bool, string, error) {
+    var seen []string
+    for _, c := range conds {
+      done, observed, err := c.Check(s)
+      if err != nil || done {
+        return done, observed, err
+      }
+      seen = append(seen, fmt.Sprintf("%s: %s", c, observed))
+    }
+    return false, strings.Join(seen, "; "), nil
+  }}
+}
+
+// Not is done while cond is not
+func Not(cond Condition) Condition {
+  return condition{fmt.Sprintf("not (%s)", cond), func(s *Session) (bool, string, error) {
+    done, observed, err := cond.Check(s)
+    return !done && err == nil, observed, err
+  }}
+}
+
+func describe(conds []Condition, sep string) string {
+  descs := make([]string, len(conds))
+  for i, c := range conds {
+    descs[i] = "(" + c.String() + ")"
+  }
+  return strings.Join(descs, sep)
+}
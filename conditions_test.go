@@ -0,0 +1,110 @@
+package webdriver
+
+import (
+  "context"
+  "errors"
+  "github.com/sourcegraph/go-selenium"
+  "strings"
+  "testing"
+  "time"
+)
+
+func Test_conditions(t *testing.T) {
+  s, srv := newFakeSession(t)
+  loginPage(srv)
+  s.Drv.Get(loginURL)
+
+  cases := []struct {
+    cond Condition
+    done bool
+  }{
+    {ElementAppears(CSS(`form[name="loginForm"]`)), true},
+    {ElementAppears(CSS(`form[name="registerForm"]`)), false},
+    {ElementVanishes(CSS(`div[class='selenium-flag']`)), true},
+    {ElementVanishes(CSS(`form[name="loginForm"]`)), false},
+    {URLIs("https://plog.org:8004/#/album", loginURL), true},
+    {URLIs("https://plog.org:8004/#/album"), false},
+    {TextEquals(CSS(`p[name='LoginMessage']`), "Authentication failed"), true},
+    {TextEquals(CSS(`p[name='LoginMessage']`), ""), false},
+    {AttributeIs(CSS(`[name="ClearPassword"]`), "type", "password"), true},
+    {ElementCount(CSS(`input`), 2), true},
+    {ElementCount(CSS(`input`), 3), false},
+    {And(ElementAppears(CSS(`form`)), URLIs(loginURL)), true},
+    {And(ElementAppears(CSS(`form`)), URLIs("elsewhere")), false},
+    {Or(ElementAppears(CSS(`table`)), URLIs(loginURL)), true},
+    {Or(ElementAppears(CSS(`table`)), URLIs("elsewhere")), false},
+    {Not(ElementAppears(CSS(`table`))), true},
+    {Not(ElementAppears(CSS(`form`))), false},
+  }
+
+  for _, c := range cases {
+    done, observed, err := c.cond.Check(s)
+    if err != nil {
+      t.Errorf("%s: unexpected error %s", c.cond, err)
+    }
+    if done != c.done {
+      t.Errorf("%s: expected done=%v, got %v (observed %s)", c.cond, c.done, done, observed)
+    }
+  }
+}
+
+func Test_condition_timeout_message(t *testing.T) {
+  s, srv := newFakeSession(t)
+  loginPage(srv)
+  s.Drv.Get(loginURL)
+
+  ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+  defer cancel()
+
+  err := s.WaitUntil(ctx, TextEquals(CSS(`p[name='LoginMessage']`), ""))
+  var te *TimeoutError
+  if !errors.As(err, &te) {
+    t.Fatalf("Expected a TimeoutError, got %v", err)
+  }
+  msg := err.Error()
+  if !strings.Contains(msg, `text of css p[name='LoginMessage'] to be ""`) || !strings.Contains(msg, `"Authentication failed"`) {
+    t.Errorf("Timeout message lacks the condition or the observation: %s", msg)
+  }
+}
+
+// nilAttrDriver hands out elements that report absent attributes the way
+// some selenium versions do, with a "nil return value" error
+type nilAttrDriver struct {
+  selenium.WebDriver
+}
+
+type nilAttrElement struct {
+  selenium.WebElement
+}
+
+func (d nilAttrDriver) FindElement(by, value string) (selenium.WebElement, error) {
+  e, err := d.WebDriver.FindElement(by, value)
+  if err != nil {
+    return nil, err
+  }
+  return nilAttrElement{e}, nil
+}
+
+func (e nilAttrElement) GetAttribute(name string) (string, error) {
+  got, err := e.WebElement.GetAttribute(name)
+  if err == nil && got == "" {
+    return "", errors.New("nil return value")
+  }
+  return got, err
+}
+
+func Test_AttributeIs_absent(t *testing.T) {
+  s, srv := newFakeSession(t)
+  loginPage(srv)
+  s.Drv.Get(loginURL)
+  s.Drv = nilAttrDriver{s.Drv}
+
+  ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+  defer cancel()
+
+  err := s.WaitUntil(ctx, AttributeIs(CSS("form"), "aria-busy", "true"))
+  var te *TimeoutError
+  if !errors.As(err, &te) || te.Observed != "attribute absent" {
+    t.Errorf("Expected to keep polling for the absent attribute, got %v", err)
+  }
+}
@@ -0,0 +1,164 @@
+package webdriver
+
+import (
+  "encoding/json"
+  "fmt"
+  "math"
+  "net/url"
+  "testing"
+  "time"
+)
+
+// Cookie is a browser cookie. It goes to and from JSON the way WebDriver
+// sends it, with the expiry in seconds since the epoch
+type Cookie struct {
+  Name     string
+  Value    string
+  Domain   string
+  Path     string
+  Expiry   time.Time // zero for a session cookie
+  Secure   bool
+  HTTPOnly bool
+  SameSite string // Strict, Lax or None, "" leaves it to the browser
+}
+
+type wireCookie struct {
+  Name     string   `json:"name"`
+  Value    string   `json:"value"`
+  Domain   string   `json:"domain,omitempty"`
+  Path     string   `json:"path,omitempty"`
+  Expiry   *float64 `json:"expiry,omitempty"`
+  Secure   bool     `json:"secure"`
+  HTTPOnly bool     `json:"httpOnly"`
+  SameSite string   `json:"sameSite,omitempty"`
+}
+
+func (c Cookie) MarshalJSON() ([]byte, error) {
+  w := wireCookie{c.Name, c.Value, c.Domain, c.Path, nil, c.Secure, c.HTTPOnly, c.SameSite}
+  if !c.Expiry.IsZero() {
+    secs := float64(c.Expiry.Unix())
+    w.Expiry = &secs
+  }
+  return json.Marshal(w)
+}
+
+func (c *Cookie) UnmarshalJSON(data []byte) error {
+  var w wireCookie
+  if err := json.Unmarshal(data, &w); err != nil {
+    return err
+  }
+  *c = Cookie{Name: w.Name, Value: w.Value, Domain: w.Domain, Path: w.Path, Secure: w.Secure, HTTPOnly: w.HTTPOnly, SameSite: w.SameSite}
+  if w.Expiry != nil {
+    secs, frac := math.Modf(*w.Expiry)
+    c.Expiry = time.Unix(int64(secs), int64(frac*1e9))
+  }
+  return nil
+}
+
+// Expired reports whether the cookie has an expiry that has passed
+func (c Cookie) Expired() bool {
+  return !c.Expiry.IsZero() && c.Expiry.Before(time.Now())
+}
+
+func (c Cookie) String() string {
+  return fmt.Sprintf("%s=%s", c.Name, c.Value)
+}
+
+// Cookies returns the cookies visible to the current page
+func (s *Session) Cookies() (cookies []Cookie, err error) {
+  err = s.command("GET", "/cookie", nil, &cookies)
+  return cookies, err
+}
+
+// Cookie returns the cookie called name, an error matching ErrNoSuchCookie if there is none
+func (s *Session) Cookie(name string) (Cookie, error) {
+  cookies, err := s.Cookies()
+  if err != nil {
+    return Cookie{}, err
+  }
+  for _, c := range cookies {
+    if c.Name == name {
+      return c, nil
+    }
+  }
+  return Cookie{}, s.wrap("get cookie "+name, nil, ErrNoSuchCookie)
+}
+
+// SetCookie adds c for the current page's domain, or replaces the cookie of
+// the same name. Browsers only take cookies for the domain they are on, so
+// open a page of the site first
+func (s *Session) SetCookie(c Cookie) error {
+  return s.command("POST", "/cookie", map[string]interface{}{"cookie": c}, nil)
+}
+
+// DeleteCookie removes the cookie called name, it is not an error if there is none
+func (s *Session) DeleteCookie(name string) error {
+  return s.command("DELETE", "/cookie/"+url.PathEscape(name), nil, nil)
+}
+
+// DeleteAllCookies removes the cookies visible to the current page
+func (s *Session) DeleteAllCookies() error {
+  return s.command("DELETE", "/cookie", nil, nil)
+}
+
+// ExpectCookie fails t unless the cookie called name is present and, if
+// predicate is not nil, satisfies it:
+//
+//   webdriver.ExpectCookie(t, "SessionToken", func(c webdriver.Cookie) bool { return len(c.Value) == 36 })
+func (s *Session) ExpectCookie(t testing.TB, name string, predicate func(Cookie) bool) {
+  t.Helper()
+  c, err := s.Cookie(name)
+  switch {
+  case err != nil:
+    t.Errorf("Expected cookie %s: %s", name, err)
+  case predicate != nil && !predicate(c):
+    t.Errorf("Cookie %s is not as expected: %+v", name, c)
+  }
+}
+
+// ExpectNoCookie fails t if the cookie called name is present
+func (s *Session) ExpectNoCookie(t testing.TB, name string) {
+  t.Helper()
+  c, err := s.Cookie(name)
+  if err == nil {
+    t.Errorf("Expected no cookie %s, found %s", name, c)
+  }
+}
+
+// Cookies returns the cookies of the default session
+func Cookies() ([]Cookie, error) {
+  return DefaultSession().Cookies()
+}
+
+// GetCookie returns a cookie of the default session. It is not called
+// Cookie because that is the type
+func GetCookie(name string) (Cookie, error) {
+  return DefaultSession().Cookie(name)
+}
+
+// SetCookie adds a cookie to the default session
+func SetCookie(c Cookie) error {
+  return DefaultSession().SetCookie(c)
+}
+
+// DeleteCookie removes a cookie from the default session
+func DeleteCookie(name string) error {
+  return DefaultSession().DeleteCookie(name)
+}
+
+// DeleteAllCookies removes the default session's cookies
+func DeleteAllCookies() error {
+  return DefaultSession().DeleteAllCookies()
+}
+
+// ExpectCookie checks a cookie of the default session
+func ExpectCookie(t testing.TB, name string, predicate func(Cookie) bool) {
+  t.Helper()
+  DefaultSession().ExpectCookie(t, name, predicate)
+}
+
+// ExpectNoCookie checks the default session has no cookie called name
+func ExpectNoCookie(t testing.TB, name string) {
+  t.Helper()
+  DefaultSession().ExpectNoCookie(t, name)
+}
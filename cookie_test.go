@@ -0,0 +1,125 @@
+package webdriver
+
+import (
+  "code.grantmurray.com/webdriver/fakewd"
+  "errors"
+  "github.com/sourcegraph/go-selenium"
+  "strings"
+  "testing"
+  "time"
+)
+
+func Test_Cookies(t *testing.T) {
+  s, srv := newFakeSession(t)
+  loginPage(srv)
+  s.Drv.Get(loginURL)
+  b := srv.Sessions()[0]
+
+  expiry := time.Unix(1900000000, 0)
+  err := s.SetCookie(Cookie{Name: "SessionToken", Value: "abc", Path: "/", Domain: "plog.org", Expiry: expiry, Secure: true, HTTPOnly: true, SameSite: "Strict"})
+  if err != nil {
+    t.Fatalf("Cannot set a cookie: %s", err)
+  }
+  want := fakewd.Cookie{Name: "SessionToken", Value: "abc", Path: "/", Domain: "plog.org", Expiry: 1900000000, Secure: true, HTTPOnly: true, SameSite: "Strict"}
+  if got, _ := b.Cookie("SessionToken"); got != want {
+    t.Errorf("Expected the browser to get %+v, got %+v", want, got)
+  }
+
+  b.SetCookie(fakewd.Cookie{Name: "lang", Value: "en"})
+  cookies, err := s.Cookies()
+  if err != nil || len(cookies) != 2 {
+    t.Fatalf("Expected 2 cookies, got %v %v", cookies, err)
+  }
+  c, err := s.Cookie("SessionToken")
+  if err != nil || !c.Expiry.Equal(expiry) || !c.Secure || !c.HTTPOnly || c.SameSite != "Strict" || c.Expired() {
+    t.Errorf("Expected the typed fields back, got %+v %v", c, err)
+  }
+  if c, err = s.Cookie("lang"); err != nil || !c.Expiry.IsZero() {
+    t.Errorf("Expected a session cookie, got %+v %v", c, err)
+  }
+
+  if err = s.DeleteCookie("lang"); err != nil {
+    t.Errorf("Cannot delete a cookie: %s", err)
+  }
+  if _, err = s.Cookie("lang"); !errors.Is(err, ErrNoSuchCookie) {
+    t.Errorf("Expected ErrNoSuchCookie after deleting, got %v", err)
+  }
+  if err = s.DeleteAllCookies(); err != nil || len(b.Cookies) != 0 {
+    t.Errorf("Expected no cookies left, got %v %v", b.Cookies, err)
+  }
+}
+
+func Test_ExpectCookie(t *testing.T) {
+  s, srv := newFakeSession(t)
+  loginPage(srv)
+  s.Drv.Get(loginURL)
+  srv.Sessions()[0].SetCookie(fakewd.Cookie{Name: "SessionToken", Value: "abc"})
+
+  tb := &recordingTB{TB: t}
+  s.ExpectCookie(tb, "SessionToken", func(c Cookie) bool { return c.Value == "abc" })
+  s.ExpectNoCookie(tb, "lang")
+  if tb.failed {
+    t.Errorf("Expected the cookie checks to pass, got %q", tb.logs)
+  }
+
+  for name, check := range map[string]func(testing.TB){
+    "missing":   func(tb testing.TB) { s.ExpectCookie(tb, "lang", nil) },
+    "predicate": func(tb testing.TB) { s.ExpectCookie(tb, "SessionToken", func(c Cookie) bool { return len(c.Value) == 36 }) },
+    "present":   func(tb testing.TB) { s.ExpectNoCookie(tb, "SessionToken") },
+  } {
+    tb := &recordingTB{TB: t}
+    check(tb)
+    if !tb.failed {
+      t.Errorf("Expected the %s check to fail", name)
+    }
+  }
+}
+
+func Test_NewSession_server_url(t *testing.T) {
+  remote, srv := newFakeSession(t)
+  loginPage(srv)
+  remote.Drv.Get(loginURL)
+
+  s := NewSession(remote.Drv)
+  if _, err := s.Cookies(); !errors.Is(err, ErrNoServerURL) {
+    t.Errorf("Expected ErrNoServerURL without a server url, got %v", err)
+  }
+  s.Config.URL = srv.URL
+  if _, err := s.Cookies(); err != nil {
+    t.Errorf("Expected cookies once the server url is set, got %v", err)
+  }
+}
+
+// anonymousDriver hides the session id from webdriver
+type anonymousDriver struct {
+  selenium.WebDriver
+}
+
+func (d anonymousDriver) Capabilities() (selenium.Capabilities, error) {
+  return selenium.Capabilities{}, nil
+}
+
+func Test_Cookies_two_sessions(t *testing.T) {
+  s1, srv := newFakeSession(t)
+  loginPage(srv)
+  s2, err := NewRemoteSession(WithURL(srv.URL))
+  if err != nil {
+    t.Fatal(err)
+  }
+  defer s2.Quit()
+  s1.Drv.Get(loginURL)
+  s2.Drv.Get(loginURL)
+
+  if err = s1.SetCookie(Cookie{Name: "SessionToken", Value: "one"}); err != nil {
+    t.Fatalf("Cannot set a cookie with two sessions on the server: %s", err)
+  }
+  if _, err = s2.Cookie("SessionToken"); !errors.Is(err, ErrNoSuchCookie) {
+    t.Errorf("Expected the cookie only in the first session, got %v", err)
+  }
+
+  s3 := NewSession(anonymousDriver{s1.Drv})
+  s3.Config.URL = srv.URL
+  if _, err = s3.Cookies(); err == nil || !strings.Contains(err.Error(), "Cannot tell the id") {
+    t.Errorf("Expected a clear error without a session id, got %v", err)
+  }
+}
@@ -0,0 +1,138 @@
+package webdriver
+
+import (
+  "errors"
+  "fmt"
+  "strings"
+)
+
+// Sentinels for the kinds of WebDriver failure callers commonly act on, test
+// for them with errors.Is
+var (
+  ErrNoSuchElement          = errors.New("no such element")
+  ErrStaleElement           = errors.New("stale element reference")
+  ErrTimeout                = errors.New("timeout")
+  ErrElementNotInteractable = errors.New("element not interactable")
+  ErrSessionNotCreated      = errors.New("session not created")
+  ErrJavaScript             = errors.New("javascript error")
+  ErrUnknownCommand         = errors.New("unknown command") // the server does not implement the command
+  ErrNoSuchCookie           = errors.New("no such cookie")
+  ErrNoSuchAttribute        = errors.New("no such attribute") // some selenium versions report an absent attribute as "nil return value"
+)
+
+// messageErrors maps the messages selenium reports for WebDriver status codes,
+// as well as the W3C error names, to sentinels
+var messageErrors = map[string]error{
+  "no such element":               ErrNoSuchElement,
+  "stale element reference":       ErrStaleElement,
+  "element not visible":           ErrElementNotInteractable,
+  "invalid element state":         ErrElementNotInteractable,
+  "element is not selectable":     ErrElementNotInteractable,
+  "element not interactable":      ErrElementNotInteractable,
+  "element click intercepted":     ErrElementNotInteractable,
+  "invalid element coordinates":   ErrElementNotInteractable,
+  "move target out of bounds":     ErrElementNotInteractable,
+  "javascript error":              ErrJavaScript,
+  "timeout":                       ErrTimeout,
+  "script timeout":                ErrTimeout,
+  "session not created":           ErrSessionNotCreated,
+  "session not created exception": ErrSessionNotCreated,
+  "unknown command":               ErrUnknownCommand,
+  "unknown method":                ErrUnknownCommand,
+  "no such cookie":                ErrNoSuchCookie,
+  "nil return value":              ErrNoSuchAttribute,
+}
+
+// statusErrors maps JSON wire protocol status codes to sentinels. selenium
+// names the older codes itself, newer ones reach us as "unknown error - <code>"
+var statusErrors = map[int]error{
+  7:  ErrNoSuchElement,
+  9:  ErrUnknownCommand,
+  10: ErrStaleElement,
+  11: ErrElementNotInteractable,
+  12: ErrElementNotInteractable,
+  15: ErrElementNotInteractable,
+  17: ErrJavaScript,
+  21: ErrTimeout,
+  28: ErrTimeout,
+  29: ErrElementNotInteractable,
+  33: ErrSessionNotCreated,
+  34: ErrElementNotInteractable,
+  60: ErrElementNotInteractable,
+  64: ErrElementNotInteractable,
+}
+
+// kindOf returns the sentinel matching a driver error, or nil
+func kindOf(err error) error {
+  msg := err.Error()
+  if kind, ok := messageErrors[msg]; ok {
+    return kind
+  }
+  var code int
+  if _, e := fmt.Sscanf(msg, "unknown error - %d", &code); e == nil {
+    return statusErrors[code]
+  }
+  for m, kind := range messageErrors {
+    if strings.HasPrefix(msg, m+":") {
+      return kind
+    }
+  }
+  return nil
+}
+
+// Error is a failed WebDriver command together with where it happened
+type Error struct {
+  Op      string // what was being done, e.g. "find" or "click"
+  Locator string // the element involved, if any
+  URL     string // the page the browser was on, if known
+  Kind    error  // one of the Err* sentinels, nil when the failure is of another kind
+  Err     error  // the error reported by the driver
+}
+
+func (e *Error) Error() string {
+  msg := e.Op
+  if e.Locator != "" {
+    msg += " " + e.Locator
+  }
+  msg += ": " + e.Err.Error()
+  if e.URL != "" {
+    msg += " (at " + e.URL + ")"
+  }
+  return msg
+}
+
+// Is makes errors.Is(err, ErrNoSuchElement) and friends work
+func (e *Error) Is(target error) bool {
+  return e.Kind != nil && target == e.Kind
+}
+
+func (e *Error) Unwrap() error {
+  return e.Err
+}
+
+// wrap turns a driver error into an *Error, noting the current url. That
+// costs a round trip, so it is left out for the not found and stale errors
+// that WaitUntil keeps polling through. A nil err stays nil
+func (s *Session) wrap(op string, loc *Locator, err error) error {
+  if err == nil {
+    return nil
+  }
+  var already *Error
+  if errors.As(err, &already) {
+    return err
+  }
+
+  e := &Error{Op: op, Kind: kindOf(err), Err: err}
+  if loc != nil {
+    e.Locator = loc.String()
+  }
+  if e.Kind != ErrSessionNotCreated && s.Drv != nil && !(s.waiting > 0 && transient(e)) {
+    e.URL, _ = s.Drv.CurrentURL()
+  }
+  return e
+}
+
+// Is lets a TimeoutError match ErrTimeout as well as the context error it wraps
+func (e *TimeoutError) Is(target error) bool {
+  return target == ErrTimeout
+}
@@ -0,0 +1,122 @@
+package webdriver
+
+import (
+  "context"
+  "errors"
+  "github.com/sourcegraph/go-selenium"
+  "strings"
+  "testing"
+  "time"
+)
+
+func Test_kindOf(t *testing.T) {
+  cases := map[string]error{
+    "no such element":         ErrNoSuchElement,
+    "stale element reference": ErrStaleElement,
+    "element not visible":     ErrElementNotInteractable,
+    "unknown error - 60":      ErrElementNotInteractable,
+    "javascript error: boom":  ErrJavaScript,
+    "script timeout":          ErrTimeout,
+    "nil return value":        ErrNoSuchAttribute,
+    "unknown error - 99":      nil,
+    "something else entirely": nil,
+  }
+  for msg, want := range cases {
+    if got := kindOf(errors.New(msg)); got != want {
+      t.Errorf("%s: expected %v, got %v", msg, want, got)
+    }
+  }
+}
+
+func Test_Error_context(t *testing.T) {
+  s, srv := newFakeSession(t)
+  loginPage(srv)
+  s.Drv.Get(loginURL)
+
+  _, err := s.Find(Name("NoSuchInput"))
+  if !errors.Is(err, ErrNoSuchElement) {
+    t.Fatalf("Expected ErrNoSuchElement, got %v", err)
+  }
+  var e *Error
+  if !errors.As(err, &e) {
+    t.Fatalf("Expected an *Error, got %T", err)
+  }
+  if e.Locator != `name "NoSuchInput"` || e.URL != loginURL {
+    t.Errorf("Error lacks context: %+v", e)
+  }
+
+  _, err = s.FetchText("p[name='NoSuchMessage']")
+  if !errors.Is(err, ErrNoSuchElement) || !strings.Contains(err.Error(), loginURL) {
+    t.Errorf("FetchText error should wrap ErrNoSuchElement and name the url, got %v", err)
+  }
+
+  _, err = s.Drv.ExecuteScript("return window.noHandlerForThis", nil)
+  if err = s.wrap("execute", nil, err); !errors.Is(err, ErrJavaScript) {
+    t.Errorf("Expected ErrJavaScript, got %v", err)
+  }
+}
+
+// urlCountingDriver counts the CurrentURL round trips
+type urlCountingDriver struct {
+  selenium.WebDriver
+  calls int
+}
+
+func (d *urlCountingDriver) CurrentURL() (string, error) {
+  d.calls++
+  return d.WebDriver.CurrentURL()
+}
+
+func Test_Error_no_url_while_polling(t *testing.T) {
+  s, srv := newFakeSession(t)
+  loginPage(srv)
+  s.Drv.Get(loginURL)
+  s.PollInterval = time.Millisecond
+  d := &urlCountingDriver{WebDriver: s.Drv}
+  s.Drv = d
+
+  ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+  defer cancel()
+  if err := s.WaitUntil(ctx, ElementAppears(Name("NoSuchInput"))); !errors.Is(err, ErrTimeout) {
+    t.Fatalf("Expected a timeout, got %v", err)
+  }
+  if d.calls != 0 {
+    t.Errorf("Expected no url lookups while polling, got %d", d.calls)
+  }
+
+  _, err := s.Find(Name("NoSuchInput"))
+  var e *Error
+  if !errors.As(err, &e) || e.URL != loginURL {
+    t.Errorf("Expected a find outside a wait to name the url, got %v", err)
+  }
+}
+
+func Test_ElementToVanish(t *testing.T) {
+  s, srv := newFakeSession(t)
+  loginPage(srv)
+  s.Drv.Get(loginURL)
+
+  if s.ElementToVanish([]interface{}{"form[name=\"loginForm\"]"}) {
+    t.Errorf("loginForm is present so it has not vanished")
+  }
+  if !s.ElementToVanish([]interface{}{"div[class='selenium-flag']"}) {
+    t.Errorf("selenium-flag is absent so it has vanished")
+  }
+}
+
+func Test_TimeoutError_Is(t *testing.T) {
+  s := NewSession(nil)
+  s.WaitTimeout = time.Millisecond
+
+  err := s.WaitUntil(context.Background(), &counter{n: 1000})
+  if !errors.Is(err, ErrTimeout) || !errors.Is(err, context.DeadlineExceeded) {
+    t.Errorf("Expected the timeout to match ErrTimeout and DeadlineExceeded, got %v", err)
+  }
+}
+
+func Test_NewRemoteSession_error(t *testing.T) {
+  _, err := NewRemoteSession(WithURL("http://127.0.0.1:1/wd/hub"))
+  if !errors.Is(err, ErrSessionNotCreated) {
+    t.Errorf("Expected ErrSessionNotCreated, got %v", err)
+  }
+}
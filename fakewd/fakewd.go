@@ -0,0 +1,572 @@
+// Package fakewd is an in-process WebDriver endpoint for unit tests that have no browser.
+//
+// It speaks enough of the JSON wire protocol for selenium.NewRemote and the
+// webdriver package to work against it. Pages are scripted ahead of time: each
+// url maps to a Page holding a tree of Elements, and the test decides what
+// happens when an element is clicked or a script is executed
+//
+//   srv := fakewd.NewServer()
+//   defer srv.Close()
+//   page := srv.Page("https://plog.org:8004/#/login")
+//   page.Add(fakewd.E("form", "name", "loginForm").Add(fakewd.E("input", "name", "UserIdentifier")))
+//   drv, err := selenium.NewRemote(nil, srv.URL)
+package fakewd
+
+import (
+  "bytes"
+  "encoding/base64"
+  "encoding/json"
+  "fmt"
+  "image"
+  "image/color"
+  "image/draw"
+  "image/png"
+  "net/http"
+  "net/http/httptest"
+  "net/url"
+  "strings"
+  "sync"
+  "time"
+)
+
+// JSON wire protocol status codes
+const (
+  StatusSuccess         = 0
+  StatusNoSuchSession   = 6
+  StatusNoSuchElement   = 7
+  StatusUnknownCommand  = 9
+  StatusStaleElement    = 10
+  StatusNotVisible      = 11
+  StatusInvalidState    = 12
+  StatusUnknownError    = 13
+  StatusJavaScriptError = 17
+  StatusTimeout         = 21
+  StatusInvalidSelector = 32
+  StatusNoSession       = 33
+)
+
+// Error is returned from ScriptFunc and OnClick handlers to make the command fail with a specific status
+type Error struct {
+  Status  int
+  Message string
+}
+
+func (e *Error) Error() string { return e.Message }
+
+// ScriptFunc answers an ExecuteScript call, args have element references replaced by *Element.
+// Like OnLoad, OnClick and OnSubmit it runs without the server's lock and
+// should work through the Browser it is handed: the Browser, Page and
+// Element methods lock the server themselves, while a Server method called
+// from a callback never sees the command in progress
+type ScriptFunc func(b *Browser, args []interface{}) (interface{}, error)
+
+// Server is a fake WebDriver remote end
+type Server struct {
+  *httptest.Server
+
+  // Capabilities are returned by new session requests, merged over the desired capabilities
+  Capabilities map[string]interface{}
+
+  // ElementScreenshots turns on the element screenshot command, older servers do not have it
+  ElementScreenshots bool
+  // NoBrowserLog answers the log command with unknown command, as geckodriver does
+  NoBrowserLog bool
+  // W3CLocators rejects the legacy id and name strategies, as W3C drivers like geckodriver do
+  W3CLocators bool
+
+  mu       sync.Mutex
+  pages    map[string]*Page
+  elements map[string]*Element
+  scripts  []script
+  sessions map[string]*Browser
+  nextID   int
+}
+
+type script struct {
+  fragment string
+  fn       ScriptFunc
+}
+
+// NewServer starts a fake WebDriver server, Close it when done
+func NewServer() *Server {
+  srv := &Server{
+    Capabilities: map[string]interface{}{},
+    pages:        map[string]*Page{},
+    elements:     map[string]*Element{},
+    sessions:     map[string]*Browser{},
+  }
+  // the built in handlers run under the server's lock, unlike the ones tests register
+  srv.handleLocked("document.readyState", func(b *Browser, args []interface{}) (interface{}, error) {
+    return b.page().ReadyState, nil
+  })
+  // webdriver measures elements with getBoundingClientRect and wants [left, top, width, height, devicePixelRatio]
+  srv.handleLocked("getBoundingClientRect", func(b *Browser, args []interface{}) (interface{}, error) {
+    e, ok := args[0].(*Element)
+    if !ok {
+      return nil, fmt.Errorf("getBoundingClientRect needs an element")
+    }
+    return []float64{float64(e.X), float64(e.Y), float64(e.Width), float64(e.Height), b.page().scale()}, nil
+  })
+  // webdriver's storage helpers run one script with arguments area, operation, key, value
+  srv.handleLocked("area.getItem", func(b *Browser, args []interface{}) (interface{}, error) {
+    return b.storage(args)
+  })
+  // webdriver's Navigate labels the document, then checks whether it was replaced or its hash changed
+  srv.handleLocked("__webdriverNavigation = arguments[0]", func(b *Browser, args []interface{}) (interface{}, error) {
+    b.Globals["__webdriverNavigation"] = args[0]
+    delete(b.Globals, "hashchange")
+    return nil, nil
+  })
+  srv.handleLocked("__webdriverNavigation === arguments[0]", func(b *Browser, args []interface{}) (interface{}, error) {
+    same := b.Globals["__webdriverNavigation"] == args[0]
+    return []interface{}{same, b.Globals["hashchange"] == true, b.URL, b.page().ReadyState}, nil
+  })
+  srv.Server = httptest.NewServer(http.HandlerFunc(srv.serveHTTP))
+  return srv
+}
+
+// Page returns the page served for url, creating an empty one if needed
+func (srv *Server) Page(url string) *Page {
+  srv.mu.Lock()
+  defer srv.mu.Unlock()
+  return srv.page(url)
+}
+
+// handleLocked registers a built in script handler, which works on the
+// Browser directly and so takes the lock that callbacks run without
+func (srv *Server) handleLocked(fragment string, fn ScriptFunc) {
+  srv.HandleScript(fragment, func(b *Browser, args []interface{}) (interface{}, error) {
+    srv.mu.Lock()
+    defer srv.mu.Unlock()
+    return fn(b, args)
+  })
+}
+
+// unlocked runs a test's callback with the server's lock released, so that
+// the callback can use methods that take it
+func (srv *Server) unlocked(fn func()) {
+  srv.mu.Unlock()
+  defer srv.mu.Lock()
+  fn()
+}
+
+func (srv *Server) page(url string) *Page {
+  p, ok := srv.pages[url]
+  if !ok {
+    p = &Page{URL: url, ReadyState: "complete", Width: 800, Height: 600, srv: srv}
+    p.root = &Element{Tag: "html", Attrs: map[string]string{}, page: p}
+    srv.pages[url] = p
+  }
+  return p
+}
+
+// HandleScript registers fn for every executed script containing fragment.
+// Later registrations win, so a test can override the built in handlers
+func (srv *Server) HandleScript(fragment string, fn ScriptFunc) {
+  srv.mu.Lock()
+  defer srv.mu.Unlock()
+  srv.scripts = append(srv.scripts, script{fragment, fn})
+}
+
+// Sessions returns the browsers that are currently open
+func (srv *Server) Sessions() []*Browser {
+  srv.mu.Lock()
+  defer srv.mu.Unlock()
+  var bs []*Browser
+  for _, b := range srv.sessions {
+    bs = append(bs, b)
+  }
+  return bs
+}
+
+// Page is one url worth of fake document
+type Page struct {
+  URL        string
+  Title      string
+  ReadyState string
+  Source     string
+  Width      int // size of the viewport and of the default screenshot
+  Height     int
+  Scale      float64     // window.devicePixelRatio, 0 means 1
+  Screenshot image.Image // served by the screenshot command, a blank image of Width x Height (times Scale) if nil
+
+  // OnLoad runs each time a browser navigates to the page
+  OnLoad func(b *Browser)
+
+  root *Element
+  srv  *Server
+}
+
+// Add appends elements to the top level of the page and returns the page
+func (p *Page) Add(elems ...*Element) *Page {
+  defer p.lock()()
+  p.root.add(elems)
+  return p
+}
+
+// Find returns the first element on the page matching a CSS selector, or nil
+func (p *Page) Find(css string) *Element {
+  defer p.lock()()
+  found, err := p.root.find("css selector", css)
+  if err != nil || len(found) == 0 {
+    return nil
+  }
+  return found[0]
+}
+
+// Element is a node in a fake document
+type Element struct {
+  Tag       string
+  Attrs     map[string]string
+  Text      string
+  Selected  bool
+  Hidden    bool
+  Disabled  bool
+  X, Y      int
+  Width     int
+  Height    int
+  Stale     bool // commands on a stale element fail with StatusStaleElement
+  CSS       map[string]string
+  Children  []*Element
+  Parent    *Element
+  OnClick   func(b *Browser) error
+  OnSubmit  func(b *Browser) error
+
+  id   string
+  page *Page
+}
+
+// E builds an element from a tag and attribute name, value pairs
+func E(tag string, attrs ...string) *Element {
+  e := &Element{Tag: tag, Attrs: map[string]string{}, CSS: map[string]string{}}
+  for i := 0; i+1 < len(attrs); i += 2 {
+    e.Attrs[attrs[i]] = attrs[i+1]
+  }
+  return e
+}
+
+// WithText sets the element's visible text and returns the element
+func (e *Element) WithText(text string) *Element {
+  e.Text = text
+  return e
+}
+
+// lock takes the lock of the server p belongs to, if any, and returns the unlock
+func (p *Page) lock() func() {
+  if p == nil || p.srv == nil {
+    return func() {}
+  }
+  p.srv.mu.Lock()
+  return p.srv.mu.Unlock
+}
+
+// Add appends children and returns e
+func (e *Element) Add(children ...*Element) *Element {
+  defer e.page.lock()()
+  e.add(children)
+  return e
+}
+
+func (e *Element) add(children []*Element) {
+  for _, c := range children {
+    c.Parent = e
+    c.adopt(e.page)
+  }
+  e.Children = append(e.Children, children...)
+}
+
+func (e *Element) adopt(p *Page) {
+  e.page = p
+  for _, c := range e.Children {
+    c.adopt(p)
+  }
+}
+
+// Remove takes e out of the page
+func (e *Element) Remove() {
+  defer e.page.lock()()
+  if e.Parent == nil {
+    return
+  }
+  kids := e.Parent.Children
+  for i, c := range kids {
+    if c == e {
+      e.Parent.Children = append(kids[:i:i], kids[i+1:]...)
+      break
+    }
+  }
+  e.Parent = nil
+}
+
+// Value is a shortcut for the value attribute
+func (e *Element) Value() string { return e.Attrs["value"] }
+
+// AllText is the text of e and its descendants, the way WebDriver reports it
+func (e *Element) AllText() string {
+  parts := []string{}
+  if e.Text != "" {
+    parts = append(parts, e.Text)
+  }
+  for _, c := range e.Children {
+    if t := c.AllText(); t != "" && !c.Hidden {
+      parts = append(parts, t)
+    }
+  }
+  return strings.Join(parts, " ")
+}
+
+// Browser is the state of one WebDriver session
+type Browser struct {
+  ID      string
+  URL     string
+  Cookies []Cookie
+  Desired map[string]interface{}
+  Scripts []string   // every script executed, in order
+  Log     []LogEntry // browser log entries not yet fetched, see Console
+
+  NetworkConditions map[string]interface{} // set by the chromium network_conditions command, nil when not emulating
+
+  // Document counts the documents loaded, going to another #fragment of the same url keeps the document
+  Document int
+  Globals  map[string]interface{} // window properties set by scripts, a new document starts without any
+
+  // web storage, shared by all pages: the fake does not separate origins
+  LocalStorage   map[string]string
+  SessionStorage map[string]string
+
+  srv     *Server
+  history []string
+  pos     int
+}
+
+// Cookie as exchanged over the wire
+type Cookie struct {
+  Name     string `json:"name"`
+  Value    string `json:"value"`
+  Path     string `json:"path,omitempty"`
+  Domain   string `json:"domain,omitempty"`
+  Secure   bool   `json:"secure,omitempty"`
+  HTTPOnly bool   `json:"httpOnly,omitempty"`
+  Expiry   uint   `json:"expiry,omitempty"`
+  SameSite string `json:"sameSite,omitempty"`
+}
+
+// LogEntry is a line of the browser log as handed out by the log endpoint
+type LogEntry struct {
+  Timestamp int64  `json:"timestamp"`
+  Level     string `json:"level"`
+  Message   string `json:"message"`
+}
+
+// Console adds a browser log entry, as a page calling console.log would
+func (b *Browser) Console(level, message string) {
+  b.srv.mu.Lock()
+  defer b.srv.mu.Unlock()
+  b.Log = append(b.Log, LogEntry{time.Now().UnixNano() / int64(time.Millisecond), level, message})
+}
+
+// Page is the page the browser is currently on
+func (b *Browser) Page() *Page {
+  b.srv.mu.Lock()
+  defer b.srv.mu.Unlock()
+  return b.page()
+}
+
+func (b *Browser) page() *Page { return b.srv.page(b.URL) }
+
+// Navigate loads url as if the user had followed a link, running the page's OnLoad
+func (b *Browser) Navigate(url string) {
+  b.srv.mu.Lock()
+  defer b.srv.mu.Unlock()
+  b.navigate(url)
+}
+
+func (b *Browser) navigate(url string) {
+  b.history = append(b.history[:b.pos+1], url)
+  b.pos = len(b.history) - 1
+  b.load(url)
+}
+
+func (b *Browser) load(url string) {
+  b.enter(url, false)
+}
+
+// enter moves to url, keeping the document for a change of fragment unless reload is set
+func (b *Browser) enter(url string, reload bool) {
+  sameDoc := !reload && b.URL != url && strings.SplitN(b.URL, "#", 2)[0] == strings.SplitN(url, "#", 2)[0]
+  if sameDoc {
+    if b.Globals != nil {
+      b.Globals["hashchange"] = true
+    }
+  } else {
+    b.Document++
+    b.Globals = map[string]interface{}{}
+  }
+  b.URL = url
+  if p := b.page(); p.OnLoad != nil {
+    b.srv.unlocked(func() { p.OnLoad(b) })
+  }
+}
+
+// storage emulates the storage script of the webdriver package
+func (b *Browser) storage(args []interface{}) (interface{}, error) {
+  if len(args) < 4 {
+    return nil, fmt.Errorf("storage script needs 4 arguments")
+  }
+  area := &b.LocalStorage
+  if args[0] == "sessionStorage" {
+    area = &b.SessionStorage
+  }
+  if *area == nil {
+    *area = map[string]string{}
+  }
+  key, _ := args[2].(string)
+
+  switch args[1] {
+  case "get":
+    if v, ok := (*area)[key]; ok {
+      return v, nil
+    }
+    return nil, nil
+  case "set":
+    (*area)[key] = fmt.Sprint(args[3])
+  case "remove":
+    delete(*area, key)
+  case "clear":
+    *area = map[string]string{}
+  case "items":
+    origin := ""
+    if u, err := url.Parse(b.URL); err == nil && u.Host != "" {
+      origin = u.Scheme + "://" + u.Host
+    }
+    items := make(map[string]string, len(*area))
+    for k, v := range *area {
+      items[k] = v
+    }
+    return map[string]interface{}{"origin": origin, "items": items}, nil
+  case "restore":
+    *area = map[string]string{}
+    items, _ := args[3].(map[string]interface{})
+    for k, v := range items {
+      (*area)[k] = fmt.Sprint(v)
+    }
+  default:
+    return nil, fmt.Errorf("unknown storage operation %v", args[1])
+  }
+  return nil, nil
+}
+
+// SetCookie adds or replaces a cookie
+func (b *Browser) SetCookie(c Cookie) {
+  b.srv.mu.Lock()
+  defer b.srv.mu.Unlock()
+  b.setCookie(c)
+}
+
+func (b *Browser) setCookie(c Cookie) {
+  b.deleteCookie(c.Name)
+  b.Cookies = append(b.Cookies, c)
+}
+
+// DeleteCookie removes the cookie called name
+func (b *Browser) DeleteCookie(name string) {
+  b.srv.mu.Lock()
+  defer b.srv.mu.Unlock()
+  b.deleteCookie(name)
+}
+
+func (b *Browser) deleteCookie(name string) {
+  for i, c := range b.Cookies {
+    if c.Name == name {
+      b.Cookies = append(b.Cookies[:i:i], b.Cookies[i+1:]...)
+      return
+    }
+  }
+}
+
+// Cookie returns the cookie called name and whether it exists
+func (b *Browser) Cookie(name string) (Cookie, bool) {
+  b.srv.mu.Lock()
+  defer b.srv.mu.Unlock()
+  for _, c := range b.Cookies {
+    if c.Name == name {
+      return c, true
+    }
+  }
+  return Cookie{}, false
+}
+
+func (p *Page) scale() float64 {
+  if p.Scale == 0 {
+    return 1
+  }
+  return p.Scale
+}
+
+// image is what a screenshot of the page shows
+func (p *Page) image() image.Image {
+  if p.Screenshot != nil {
+    return p.Screenshot
+  }
+  blank := image.NewRGBA(image.Rect(0, 0, int(float64(p.Width)*p.scale()), int(float64(p.Height)*p.scale())))
+  for i := range blank.Pix {
+    blank.Pix[i] = 0xff
+  }
+  return blank
+}
+
+// screenshot encodes the current page's screenshot as base64 png
+func (p *Page) screenshot() (string, error) {
+  return encodePNG(p.image())
+}
+
+// screenshot encodes the part of the page's screenshot covered by e
+func (e *Element) screenshot(p *Page) (string, error) {
+  sc := p.scale()
+  r := image.Rect(int(float64(e.X)*sc), int(float64(e.Y)*sc), int(float64(e.X+e.Width)*sc), int(float64(e.Y+e.Height)*sc))
+  img := p.image()
+  crop := image.NewRGBA(image.Rect(0, 0, r.Dx(), r.Dy()))
+  draw.Draw(crop, crop.Bounds(), img, r.Min, draw.Src)
+  return encodePNG(crop)
+}
+
+func encodePNG(img image.Image) (string, error) {
+  var buf bytes.Buffer
+  if err := png.Encode(&buf, img); err != nil {
+    return "", err
+  }
+  return base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+// Solid returns a w x h image filled with c, handy for Page.Screenshot
+func Solid(w, h int, c color.Color) *image.RGBA {
+  img := image.NewRGBA(image.Rect(0, 0, w, h))
+  for y := 0; y < h; y++ {
+    for x := 0; x < w; x++ {
+      img.Set(x, y, c)
+    }
+  }
+  return img
+}
+
+// reply writes a JSON wire protocol response
+func reply(w http.ResponseWriter, sessionID string, status int, value interface{}) {
+  w.Header().Set("Content-Type", "application/json;charset=UTF-8")
+  json.NewEncoder(w).Encode(map[string]interface{}{
+    "sessionId": sessionID,
+    "status":    status,
+    "value":     value,
+  })
+}
+
+func replyErr(w http.ResponseWriter, sessionID string, err error) {
+  status := StatusUnknownError
+  if e, ok := err.(*Error); ok {
+    status = e.Status
+  }
+  reply(w, sessionID, status, map[string]interface{}{"message": err.Error()})
+}
+
+func errorf(status int, format string, args ...interface{}) *Error {
+  return &Error{status, fmt.Sprintf(format, args...)}
+}
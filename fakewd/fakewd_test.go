@@ -0,0 +1,107 @@
+package fakewd
+
+import (
+  "strings"
+  "testing"
+)
+
+func Test_parseCSS(t *testing.T) {
+  page := &Page{}
+  page.root = &Element{Tag: "html", Attrs: map[string]string{}, page: page}
+  page.Add(
+    E("form", "name", "loginForm", "class", "big form").Add(
+      E("div", "class", "row").Add(E("input", "name", `Say "hi"`, "id", "greet")),
+      E("div", "class", "selenium-flag"),
+    ),
+    E("p", "name", "LoginMessage"),
+  )
+
+  cases := []struct {
+    sel   string
+    count int
+  }{
+    {`form[name="loginForm"]`, 1},
+    {`div[class='selenium-flag']`, 1},
+    {`form.big.form`, 1},
+    {`form > input`, 0},
+    {`form > div > input`, 1},
+    {`form input`, 1},
+    {`#greet`, 1},
+    {`[name="Say \"hi\""]`, 1},
+    {`[name^=Login]`, 1},
+    {`p, div`, 3},
+    {`*`, 5},
+  }
+
+  for _, c := range cases {
+    found, err := page.root.find("css selector", c.sel)
+    if err != nil {
+      t.Errorf("%s: %s", c.sel, err)
+      continue
+    }
+    if len(found) != c.count {
+      t.Errorf("%s: expected %d matches, got %d", c.sel, c.count, len(found))
+    }
+  }
+
+  for _, bad := range []string{`form[name=`, `[name="x"`, `form >`, `::`} {
+    if _, err := parseCSS(bad); err == nil {
+      t.Errorf("Expected %q to be rejected", bad)
+    }
+  }
+}
+
+func Test_xpath(t *testing.T) {
+  page := &Page{}
+  page.root = &Element{Tag: "html", Attrs: map[string]string{}, page: page}
+  page.Add(
+    E("form", "name", "loginForm").Add(
+      E("label", "for", "uid").WithText("User Id"),
+      E("input", "id", "uid", "name", "UserIdentifier"),
+      E("label", "for", "pw").WithText(`Don't say "password"`),
+      E("input", "id", "pw", "name", "ClearPassword"),
+    ),
+    E("div").Add(E("span").WithText("  Save   successful ")),
+    E("a", "href", "#/logout").WithText("Logout"),
+  )
+
+  cases := []struct {
+    expr string
+    want []string // name or tag of each match
+  }{
+    {`//input`, []string{"UserIdentifier", "ClearPassword"}},
+    {`//input[@name='ClearPassword']`, []string{"ClearPassword"}},
+    {`/html/form/input[@id="uid"]`, []string{"UserIdentifier"}},
+    {`//*[@id=//label[normalize-space(.)='User Id']/@for]`, []string{"UserIdentifier"}},
+    {`//*[@id=//label[normalize-space(.)=concat('Don', "'", 't say "password"')]/@for]`, []string{"ClearPassword"}},
+    {`//*[normalize-space(.)='Save successful' and not(.//*[normalize-space(.)='Save successful'])]`, []string{"span"}},
+    {`//a[text()='Logout']`, []string{"a"}},
+    {`//form[.//input[@name='UserIdentifier']]`, []string{"loginForm"}},
+    {`//input[contains(@name, 'Password') or @id='uid']`, []string{"UserIdentifier", "ClearPassword"}},
+  }
+
+  for _, c := range cases {
+    found, err := page.root.find("xpath", c.expr)
+    if err != nil {
+      t.Errorf("%s: %s", c.expr, err)
+      continue
+    }
+    var got []string
+    for _, e := range found {
+      if n, ok := e.Attrs["name"]; ok {
+        got = append(got, n)
+      } else {
+        got = append(got, e.Tag)
+      }
+    }
+    if strings.Join(got, ",") != strings.Join(c.want, ",") {
+      t.Errorf("%s: expected %v, got %v", c.expr, c.want, got)
+    }
+  }
+
+  for _, bad := range []string{`//input[`, `//input[@name='x]`, `count(//input)`} {
+    if _, err := page.root.find("xpath", bad); err == nil {
+      t.Errorf("Expected %q to be rejected", bad)
+    }
+  }
+}
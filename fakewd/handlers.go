@@ -0,0 +1,475 @@
+package fakewd
+
+import (
+  "encoding/json"
+  "fmt"
+  "net/http"
+  "strings"
+)
+
+// serveHTTP routes JSON wire protocol commands
+func (srv *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+  var body map[string]interface{}
+  if r.Body != nil {
+    json.NewDecoder(r.Body).Decode(&body)
+  }
+
+  path := strings.Trim(r.URL.Path, "/")
+  parts := strings.Split(path, "/")
+
+  srv.mu.Lock()
+  defer srv.mu.Unlock()
+
+  switch {
+  case path == "status":
+    reply(w, "", StatusSuccess, map[string]interface{}{"ready": true, "build": map[string]string{"version": "fakewd"}})
+    return
+  case path == "session" && r.Method == "POST":
+    srv.newSession(w, body)
+    return
+  case path == "sessions":
+    var list []map[string]interface{}
+    for id, b := range srv.sessions {
+      list = append(list, map[string]interface{}{"id": id, "capabilities": b.Desired})
+    }
+    reply(w, "", StatusSuccess, list)
+    return
+  case len(parts) < 2 || parts[0] != "session":
+    reply(w, "", StatusUnknownCommand, map[string]string{"message": "unknown command " + path})
+    return
+  }
+
+  b, ok := srv.sessions[parts[1]]
+  if !ok {
+    reply(w, parts[1], StatusNoSuchSession, map[string]string{"message": "no such session " + parts[1]})
+    return
+  }
+
+  cmd := r.Method + " /" + strings.Join(parts[2:], "/")
+  value, err := srv.command(b, cmd, parts[2:], body)
+  if err != nil {
+    replyErr(w, b.ID, err)
+    return
+  }
+  reply(w, b.ID, StatusSuccess, value)
+}
+
+func (srv *Server) newSession(w http.ResponseWriter, body map[string]interface{}) {
+  srv.nextID++
+  b := &Browser{ID: fmt.Sprintf("fake-session-%d", srv.nextID), URL: "about:blank", srv: srv, history: []string{"about:blank"}, Document: 1, Globals: map[string]interface{}{}}
+
+  caps := map[string]interface{}{}
+  if desired, ok := body["desiredCapabilities"].(map[string]interface{}); ok {
+    b.Desired = desired
+    for k, v := range desired {
+      caps[k] = v
+    }
+  }
+  for k, v := range srv.Capabilities {
+    caps[k] = v
+  }
+  caps["webdriver.remote.sessionid"] = b.ID
+
+  srv.sessions[b.ID] = b
+  reply(w, b.ID, StatusSuccess, caps)
+}
+
+// command runs one session command, args are the path segments after the session id
+func (srv *Server) command(b *Browser, cmd string, args []string, body map[string]interface{}) (interface{}, error) {
+  p := b.page()
+
+  switch {
+  case cmd == "GET /":
+    caps := map[string]interface{}{"webdriver.remote.sessionid": b.ID}
+    for k, v := range b.Desired {
+      caps[k] = v
+    }
+    for k, v := range srv.Capabilities {
+      caps[k] = v
+    }
+    return caps, nil
+  case cmd == "DELETE /":
+    delete(srv.sessions, b.ID)
+    return nil, nil
+  case strings.HasPrefix(cmd, "POST /timeouts"):
+    return nil, nil
+
+  case cmd == "GET /url":
+    return b.URL, nil
+  case cmd == "POST /url":
+    url, _ := body["url"].(string)
+    b.navigate(url)
+    return nil, nil
+  case cmd == "POST /back":
+    if b.pos > 0 {
+      b.pos--
+      b.load(b.history[b.pos])
+    }
+    return nil, nil
+  case cmd == "POST /forward":
+    if b.pos < len(b.history)-1 {
+      b.pos++
+      b.load(b.history[b.pos])
+    }
+    return nil, nil
+  case cmd == "POST /refresh":
+    b.enter(b.URL, true)
+    return nil, nil
+  case cmd == "GET /title":
+    return p.Title, nil
+  case cmd == "GET /source":
+    return p.Source, nil
+  case cmd == "GET /window_handle":
+    return "main", nil
+  case cmd == "GET /window_handles":
+    return []string{"main"}, nil
+  case strings.HasPrefix(cmd, "GET /window/") && strings.HasSuffix(cmd, "/size"):
+    return map[string]int{"width": p.Width, "height": p.Height}, nil
+  case strings.HasPrefix(cmd, "POST /window/") && strings.HasSuffix(cmd, "/size"):
+    p.Width = toInt(body["width"])
+    p.Height = toInt(body["height"])
+    return nil, nil
+  case cmd == "GET /screenshot":
+    return p.screenshot()
+
+  case cmd == "GET /cookie":
+    if b.Cookies == nil {
+      return []Cookie{}, nil
+    }
+    return b.Cookies, nil
+  case cmd == "POST /cookie":
+    var c Cookie
+    if err := remarshal(body["cookie"], &c); err != nil {
+      return nil, errorf(StatusUnknownError, "bad cookie: %s", err)
+    }
+    b.setCookie(c)
+    return nil, nil
+  case cmd == "DELETE /cookie":
+    b.Cookies = nil
+    return nil, nil
+  case strings.HasPrefix(cmd, "DELETE /cookie/"):
+    b.deleteCookie(args[1])
+    return nil, nil
+
+  case srv.NoBrowserLog && (cmd == "POST /log" || cmd == "GET /log/types"):
+    break // answered as an unknown command below
+  case cmd == "GET /log/types":
+    return []string{"browser"}, nil
+  case cmd == "POST /log":
+    if body["type"] != "browser" {
+      return []LogEntry{}, nil
+    }
+    log := append([]LogEntry{}, b.Log...)
+    b.Log = nil
+    return log, nil
+
+  case cmd == "GET /chromium/network_conditions":
+    if b.NetworkConditions == nil {
+      return nil, errorf(StatusUnknownError, "unknown error: network conditions must be set before it can be retrieved")
+    }
+    return b.NetworkConditions, nil
+  case cmd == "POST /chromium/network_conditions":
+    nc, ok := body["network_conditions"].(map[string]interface{})
+    if !ok {
+      return nil, errorf(StatusUnknownError, "invalid argument: 'network_conditions' must be a dictionary")
+    }
+    b.NetworkConditions = nc
+    return nil, nil
+  case cmd == "DELETE /chromium/network_conditions":
+    b.NetworkConditions = nil
+    return nil, nil
+
+  case cmd == "POST /execute" || cmd == "POST /execute_async":
+    return srv.execute(b, body)
+
+  case cmd == "POST /element":
+    return srv.findOne(p.root, body)
+  case cmd == "POST /elements":
+    return srv.findAll(p.root, body)
+  case cmd == "POST /element/active":
+    return nil, errorf(StatusNoSuchElement, "no such element")
+  case len(args) >= 2 && args[0] == "element":
+    e, ok := srv.elements[args[1]]
+    if !ok || e.Stale || !e.attached(p) {
+      return nil, errorf(StatusStaleElement, "stale element reference")
+    }
+    method := strings.SplitN(cmd, " ", 2)[0]
+    return srv.elementCommand(b, e, method, args[2:], body)
+  }
+
+  return nil, errorf(StatusUnknownCommand, "unknown command %s", cmd)
+}
+
+func (srv *Server) elementCommand(b *Browser, e *Element, method string, args []string, body map[string]interface{}) (interface{}, error) {
+  if len(args) == 0 {
+    return nil, errorf(StatusUnknownCommand, "unknown element command")
+  }
+
+  switch method + " " + args[0] {
+  case "POST element":
+    return srv.findOne(e, body)
+  case "POST elements":
+    return srv.findAll(e, body)
+  case "POST click":
+    return nil, e.click(b)
+  case "POST submit":
+    f := e
+    if f.Tag != "form" {
+      f = e.enclosing("form")
+    }
+    if f != nil && f.OnSubmit != nil {
+      var err error
+      srv.unlocked(func() { err = f.OnSubmit(b) })
+      return nil, err
+    }
+    return nil, nil
+  case "POST clear":
+    if e.Disabled {
+      return nil, errorf(StatusInvalidState, "invalid element state")
+    }
+    e.Attrs["value"] = ""
+    return nil, nil
+  case "POST value":
+    if e.Hidden {
+      return nil, errorf(StatusNotVisible, "element not visible")
+    }
+    var keys []string
+    remarshal(body["value"], &keys)
+    e.Attrs["value"] += strings.Join(keys, "")
+    return nil, nil
+  case "GET text":
+    if e.Hidden {
+      return "", nil
+    }
+    return e.AllText(), nil
+  case "GET name":
+    return e.Tag, nil
+  case "GET attribute":
+    if len(args) < 2 {
+      break
+    }
+    return e.attribute(args[1]), nil
+  case "GET selected":
+    return e.Selected, nil
+  case "GET enabled":
+    return !e.Disabled, nil
+  case "GET displayed":
+    return !e.Hidden, nil
+  case "GET location", "GET location_in_view":
+    return map[string]int{"x": e.X, "y": e.Y}, nil
+  case "GET size":
+    return map[string]int{"width": e.Width, "height": e.Height}, nil
+  case "GET screenshot":
+    if !srv.ElementScreenshots {
+      break
+    }
+    return e.screenshot(b.page())
+  case "GET css":
+    if len(args) < 2 {
+      break
+    }
+    return e.CSS[args[1]], nil
+  }
+  return nil, errorf(StatusUnknownCommand, "unknown element command %s %s", method, strings.Join(args, "/"))
+}
+
+// attribute mimics the property-or-attribute lookup browsers do
+func (e *Element) attribute(name string) interface{} {
+  switch name {
+  case "checked", "selected":
+    if e.Selected {
+      return "true"
+    }
+    return nil
+  case "disabled":
+    if e.Disabled {
+      return "true"
+    }
+    return nil
+  case "value":
+    if e.Tag == "select" {
+      for _, o := range e.descendants() {
+        if o.Tag == "option" && o.Selected {
+          return o.optionValue()
+        }
+      }
+    }
+  }
+  if v, ok := e.Attrs[name]; ok {
+    return v
+  }
+  if name == "value" && (e.Tag == "input" || e.Tag == "textarea") {
+    return ""
+  }
+  return nil
+}
+
+func (e *Element) optionValue() string {
+  if v, ok := e.Attrs["value"]; ok {
+    return v
+  }
+  return e.Text
+}
+
+// click toggles checkboxes, selects radios and options, then runs OnClick
+func (e *Element) click(b *Browser) error {
+  if e.Hidden {
+    return errorf(StatusNotVisible, "element not visible")
+  }
+  if e.Disabled {
+    return errorf(StatusInvalidState, "invalid element state")
+  }
+
+  switch {
+  case e.Tag == "input" && e.Attrs["type"] == "checkbox":
+    e.Selected = !e.Selected
+  case e.Tag == "input" && e.Attrs["type"] == "radio":
+    for _, r := range e.page.root.descendants() {
+      if r.Tag == "input" && r.Attrs["type"] == "radio" && r.Attrs["name"] == e.Attrs["name"] {
+        r.Selected = false
+      }
+    }
+    e.Selected = true
+  case e.Tag == "option":
+    if sel := e.enclosing("select"); sel != nil {
+      if _, multi := sel.Attrs["multiple"]; multi {
+        e.Selected = !e.Selected
+        break
+      }
+      for _, o := range sel.descendants() {
+        o.Selected = false
+      }
+    }
+    e.Selected = true
+  }
+
+  if e.OnClick != nil {
+    var err error
+    b.srv.unlocked(func() { err = e.OnClick(b) })
+    return err
+  }
+  return nil
+}
+
+// enclosing returns the nearest ancestor with the given tag
+func (e *Element) enclosing(tag string) *Element {
+  for f := e.Parent; f != nil; f = f.Parent {
+    if f.Tag == tag {
+      return f
+    }
+  }
+  return nil
+}
+
+func (e *Element) descendants() []*Element {
+  var all []*Element
+  for _, c := range e.Children {
+    all = append(all, c)
+    all = append(all, c.descendants()...)
+  }
+  return all
+}
+
+// attached reports whether e is still part of page p
+func (e *Element) attached(p *Page) bool {
+  for f := e; f != nil; f = f.Parent {
+    if f == p.root {
+      return true
+    }
+  }
+  return false
+}
+
+func (srv *Server) ref(e *Element) map[string]string {
+  if e.id == "" {
+    srv.nextID++
+    e.id = fmt.Sprintf("fake-element-%d", srv.nextID)
+    srv.elements[e.id] = e
+  }
+  return map[string]string{"ELEMENT": e.id}
+}
+
+// find runs a find command's strategy and value against root
+func (srv *Server) find(root *Element, body map[string]interface{}) ([]*Element, error) {
+  using, _ := body["using"].(string)
+  value, _ := body["value"].(string)
+  if srv.W3CLocators && (using == "id" || using == "name") {
+    return nil, errorf(StatusInvalidSelector, "invalid argument: invalid locator strategy %q", using)
+  }
+  return root.find(using, value)
+}
+
+func (srv *Server) findOne(root *Element, body map[string]interface{}) (interface{}, error) {
+  found, err := srv.find(root, body)
+  if err != nil {
+    return nil, err
+  }
+  if len(found) == 0 {
+    return nil, errorf(StatusNoSuchElement, "no such element")
+  }
+  return srv.ref(found[0]), nil
+}
+
+func (srv *Server) findAll(root *Element, body map[string]interface{}) (interface{}, error) {
+  found, err := srv.find(root, body)
+  if err != nil {
+    return nil, err
+  }
+  refs := []map[string]string{}
+  for _, e := range found {
+    refs = append(refs, srv.ref(e))
+  }
+  return refs, nil
+}
+
+// execute dispatches to the most recently registered script handler that matches
+func (srv *Server) execute(b *Browser, body map[string]interface{}) (interface{}, error) {
+  src, _ := body["script"].(string)
+  b.Scripts = append(b.Scripts, src)
+
+  rawArgs, _ := body["args"].([]interface{})
+  args := make([]interface{}, len(rawArgs))
+  for i, a := range rawArgs {
+    args[i] = a
+    if m, ok := a.(map[string]interface{}); ok {
+      if id, ok := m["ELEMENT"].(string); ok {
+        if e, ok := srv.elements[id]; ok {
+          args[i] = e
+        }
+      }
+    }
+  }
+
+  for i := len(srv.scripts) - 1; i >= 0; i-- {
+    if strings.Contains(src, srv.scripts[i].fragment) {
+      var v interface{}
+      var err error
+      fn := srv.scripts[i].fn
+      srv.unlocked(func() { v, err = fn(b, args) })
+      if err != nil {
+        if _, ok := err.(*Error); !ok {
+          err = errorf(StatusJavaScriptError, "javascript error: %s", err)
+        }
+        return nil, err
+      }
+      if e, ok := v.(*Element); ok {
+        return srv.ref(e), nil
+      }
+      return v, nil
+    }
+  }
+  return nil, errorf(StatusJavaScriptError, "javascript error: fakewd has no handler for script %q", src)
+}
+
+func remarshal(in, out interface{}) error {
+  buf, err := json.Marshal(in)
+  if err != nil {
+    return err
+  }
+  return json.Unmarshal(buf, out)
+}
+
+func toInt(v interface{}) int {
+  f, _ := v.(float64)
+  return int(f)
+}
@@ -0,0 +1,327 @@
+package fakewd
+
+import (
+  "strings"
+)
+
+// find returns the descendants of e matching a locator strategy, in document order
+func (e *Element) find(using, value string) ([]*Element, error) {
+  var match func(*Element) bool
+
+  switch using {
+  case "css selector":
+    sel, err := parseCSS(value)
+    if err != nil {
+      return nil, err
+    }
+    match = func(c *Element) bool { return sel.matches(c, e) }
+  case "xpath":
+    return e.xpathFind(value)
+  case "id":
+    match = func(c *Element) bool { return c.Attrs["id"] == value }
+  case "name":
+    match = func(c *Element) bool { return c.Attrs["name"] == value }
+  case "tag name":
+    match = func(c *Element) bool { return c.Tag == value }
+  case "class name":
+    match = func(c *Element) bool { return hasWord(c.Attrs["class"], value) }
+  case "link text":
+    match = func(c *Element) bool { return c.Tag == "a" && strings.TrimSpace(c.AllText()) == value }
+  case "partial link text":
+    match = func(c *Element) bool { return c.Tag == "a" && strings.Contains(c.AllText(), value) }
+  default:
+    return nil, errorf(StatusInvalidSelector, "invalid selector: unsupported strategy %q", using)
+  }
+
+  var found []*Element
+  for _, c := range e.descendants() {
+    if match(c) {
+      found = append(found, c)
+    }
+  }
+  return found, nil
+}
+
+func hasWord(list, word string) bool {
+  for _, w := range strings.Fields(list) {
+    if w == word {
+      return true
+    }
+  }
+  return false
+}
+
+// cssGroup is a comma separated list of selectors
+type cssGroup []cssSelector
+
+// cssSelector is a chain of compounds, read right to left when matching
+type cssSelector []cssStep
+
+type cssStep struct {
+  child    bool // combinator to the previous step is '>' rather than descendant
+  compound cssCompound
+}
+
+type cssCompound struct {
+  tag   string
+  id    string
+  class []string
+  attrs []cssAttr
+}
+
+type cssAttr struct {
+  name, op, value string
+}
+
+func (g cssGroup) matches(e, scope *Element) bool {
+  for _, s := range g {
+    if s.matches(e, len(s)-1, scope) {
+      return true
+    }
+  }
+  return false
+}
+
+// matches checks steps[0..i] against e and its ancestors below scope
+func (s cssSelector) matches(e *Element, i int, scope *Element) bool {
+  if !s[i].compound.matches(e) {
+    return false
+  }
+  if i == 0 {
+    return true
+  }
+  for a := e.Parent; a != nil && a != scope; a = a.Parent {
+    if s.matches(a, i-1, scope) {
+      return true
+    }
+    if s[i].child {
+      return false
+    }
+  }
+  return false
+}
+
+func (c cssCompound) matches(e *Element) bool {
+  if c.tag != "" && c.tag != "*" && c.tag != e.Tag {
+    return false
+  }
+  if c.id != "" && e.Attrs["id"] != c.id {
+    return false
+  }
+  for _, cl := range c.class {
+    if !hasWord(e.Attrs["class"], cl) {
+      return false
+    }
+  }
+  for _, a := range c.attrs {
+    v, ok := e.Attrs[a.name]
+    if !ok {
+      return false
+    }
+    switch a.op {
+    case "":
+    case "=":
+      ok = v == a.value
+    case "~=":
+      ok = hasWord(v, a.value)
+    case "^=":
+      ok = strings.HasPrefix(v, a.value)
+    case "$=":
+      ok = strings.HasSuffix(v, a.value)
+    case "*=":
+      ok = strings.Contains(v, a.value)
+    }
+    if !ok {
+      return false
+    }
+  }
+  return true
+}
+
+// parseCSS understands type, universal, id, class and attribute selectors
+// joined by descendant and child combinators, which is all our tests use
+func parseCSS(src string) (cssGroup, error) {
+  p := &cssParser{src: src}
+  var g cssGroup
+  for {
+    s, err := p.selector()
+    if err != nil {
+      return nil, err
+    }
+    g = append(g, s)
+    p.skipSpace()
+    if p.eof() {
+      return g, nil
+    }
+    if p.peek() != ',' {
+      return nil, p.fail()
+    }
+    p.pos++
+  }
+}
+
+type cssParser struct {
+  src string
+  pos int
+}
+
+func (p *cssParser) eof() bool  { return p.pos >= len(p.src) }
+func (p *cssParser) peek() byte { return p.src[p.pos] }
+
+func (p *cssParser) fail() error {
+  return errorf(StatusInvalidSelector, "invalid selector: cannot parse %q at offset %d", p.src, p.pos)
+}
+
+func (p *cssParser) skipSpace() bool {
+  start := p.pos
+  for !p.eof() && strings.IndexByte(" \t\n", p.peek()) >= 0 {
+    p.pos++
+  }
+  return p.pos > start
+}
+
+func (p *cssParser) selector() (cssSelector, error) {
+  var s cssSelector
+  p.skipSpace()
+  child := false
+  for {
+    c, err := p.compound()
+    if err != nil {
+      return nil, err
+    }
+    s = append(s, cssStep{child, c})
+
+    spaced := p.skipSpace()
+    if p.eof() || p.peek() == ',' {
+      return s, nil
+    }
+    child = false
+    if p.peek() == '>' {
+      child = true
+      p.pos++
+      p.skipSpace()
+    } else if !spaced {
+      return nil, p.fail()
+    }
+  }
+}
+
+func (p *cssParser) compound() (c cssCompound, err error) {
+  if !p.eof() && p.peek() == '*' {
+    c.tag = "*"
+    p.pos++
+  } else {
+    c.tag = p.ident()
+  }
+
+  for !p.eof() {
+    switch p.peek() {
+    case '#':
+      p.pos++
+      if c.id = p.ident(); c.id == "" {
+        return c, p.fail()
+      }
+    case '.':
+      p.pos++
+      cl := p.ident()
+      if cl == "" {
+        return c, p.fail()
+      }
+      c.class = append(c.class, cl)
+    case '[':
+      p.pos++
+      a, err := p.attr()
+      if err != nil {
+        return c, err
+      }
+      c.attrs = append(c.attrs, a)
+    default:
+      if c.tag == "" && c.id == "" && len(c.class) == 0 && len(c.attrs) == 0 {
+        return c, p.fail()
+      }
+      return c, nil
+    }
+  }
+  if c.tag == "" && c.id == "" && len(c.class) == 0 && len(c.attrs) == 0 {
+    return c, p.fail()
+  }
+  return c, nil
+}
+
+func (p *cssParser) attr() (a cssAttr, err error) {
+  p.skipSpace()
+  if a.name = p.ident(); a.name == "" {
+    return a, p.fail()
+  }
+  p.skipSpace()
+  if p.eof() {
+    return a, p.fail()
+  }
+  if p.peek() == ']' {
+    p.pos++
+    return a, nil
+  }
+
+  for _, op := range []string{"=", "~=", "^=", "$=", "*="} {
+    if strings.HasPrefix(p.src[p.pos:], op) {
+      a.op = op
+      p.pos += len(op)
+      break
+    }
+  }
+  if a.op == "" {
+    return a, p.fail()
+  }
+  p.skipSpace()
+
+  if p.eof() {
+    return a, p.fail()
+  }
+  if q := p.peek(); q == '"' || q == '\'' {
+    if a.value, err = p.quoted(q); err != nil {
+      return a, err
+    }
+  } else if a.value = p.ident(); a.value == "" {
+    return a, p.fail()
+  }
+
+  p.skipSpace()
+  if p.eof() || p.peek() != ']' {
+    return a, p.fail()
+  }
+  p.pos++
+  return a, nil
+}
+
+// quoted reads a string delimited by q, honouring backslash escapes
+func (p *cssParser) quoted(q byte) (string, error) {
+  p.pos++
+  var b strings.Builder
+  for !p.eof() {
+    c := p.peek()
+    p.pos++
+    switch {
+    case c == q:
+      return b.String(), nil
+    case c == '\\' && !p.eof():
+      b.WriteByte(p.peek())
+      p.pos++
+    default:
+      b.WriteByte(c)
+    }
+  }
+  return "", p.fail()
+}
+
+func (p *cssParser) ident() string {
+  start := p.pos
+  for !p.eof() {
+    c := p.peek()
+    if c == '-' || c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80 {
+      p.pos++
+      continue
+    }
+    break
+  }
+  return p.src[start:p.pos]
+}
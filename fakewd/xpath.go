@@ -0,0 +1,479 @@
+package fakewd
+
+import (
+  "strings"
+)
+
+// The XPath support here is a small subset of XPath 1.0: location paths made
+// of child (/) and descendant (//) steps over element names, *, ., .., @attr
+// and text(), predicates, = and !=, and, or, and the functions
+// normalize-space, not, contains, starts-with, concat and string. That covers
+// the expressions the webdriver package generates for Text and LabelFor
+
+// xpathFind evaluates expr with e as the context node and returns the elements it selects
+func (e *Element) xpathFind(expr string) ([]*Element, error) {
+  p := &xpParser{src: expr}
+  if err := p.tokenize(); err != nil {
+    return nil, err
+  }
+  node, err := p.expr()
+  if err != nil {
+    return nil, err
+  }
+  if p.pos != len(p.toks) {
+    return nil, p.fail()
+  }
+
+  doc := &Element{Children: []*Element{e.page.root}}
+  v := node.eval(xpCtx{e, doc})
+  if v.kind != xpNodes {
+    return nil, errorf(StatusInvalidSelector, "invalid selector: %q does not select elements", expr)
+  }
+
+  // document order, without duplicates
+  wanted := make(map[*Element]bool, len(v.nodes))
+  for _, n := range v.nodes {
+    wanted[n] = true
+  }
+  var found []*Element
+  for _, c := range e.page.root.descendants() {
+    if wanted[c] {
+      found = append(found, c)
+    }
+  }
+  if wanted[e.page.root] {
+    found = append([]*Element{e.page.root}, found...)
+  }
+  return found, nil
+}
+
+type xpKind int
+
+const (
+  xpNodes   xpKind = iota // element node-set
+  xpStrings               // attribute or text node-set, held as their string values
+  xpString
+  xpBool
+)
+
+type xpValue struct {
+  kind  xpKind
+  nodes []*Element
+  strs  []string
+  s     string
+  b     bool
+}
+
+func (v xpValue) boolean() bool {
+  switch v.kind {
+  case xpNodes:
+    return len(v.nodes) > 0
+  case xpStrings:
+    return len(v.strs) > 0
+  case xpString:
+    return v.s != ""
+  }
+  return v.b
+}
+
+// str is the string-value, for a node-set that of its first node
+func (v xpValue) str() string {
+  switch v.kind {
+  case xpNodes:
+    if len(v.nodes) > 0 {
+      return v.nodes[0].AllText()
+    }
+    return ""
+  case xpStrings:
+    if len(v.strs) > 0 {
+      return v.strs[0]
+    }
+    return ""
+  case xpBool:
+    if v.b {
+      return "true"
+    }
+    return "false"
+  }
+  return v.s
+}
+
+// all returns every string the value stands for, so comparisons can be existential
+func (v xpValue) all() []string {
+  switch v.kind {
+  case xpNodes:
+    out := make([]string, len(v.nodes))
+    for i, n := range v.nodes {
+      out[i] = n.AllText()
+    }
+    return out
+  case xpStrings:
+    return v.strs
+  }
+  return []string{v.str()}
+}
+
+type xpCtx struct {
+  node *Element
+  doc  *Element
+}
+
+type xpNode interface {
+  eval(c xpCtx) xpValue
+}
+
+type xpLiteral string
+
+func (l xpLiteral) eval(c xpCtx) xpValue { return xpValue{kind: xpString, s: string(l)} }
+
+type xpBinary struct {
+  op          string
+  left, right xpNode
+}
+
+func (b xpBinary) eval(c xpCtx) xpValue {
+  switch b.op {
+  case "and":
+    return xpValue{kind: xpBool, b: b.left.eval(c).boolean() && b.right.eval(c).boolean()}
+  case "or":
+    return xpValue{kind: xpBool, b: b.left.eval(c).boolean() || b.right.eval(c).boolean()}
+  }
+
+  l, r := b.left.eval(c), b.right.eval(c)
+  if l.kind == xpBool || r.kind == xpBool {
+    eq := l.boolean() == r.boolean()
+    return xpValue{kind: xpBool, b: eq == (b.op == "=")}
+  }
+  for _, ls := range l.all() {
+    for _, rs := range r.all() {
+      if (ls == rs) == (b.op == "=") {
+        return xpValue{kind: xpBool, b: true}
+      }
+    }
+  }
+  return xpValue{kind: xpBool, b: false}
+}
+
+type xpCall struct {
+  name string
+  args []xpNode
+}
+
+func (f xpCall) eval(c xpCtx) xpValue {
+  arg := func(i int) xpValue {
+    if i < len(f.args) {
+      return f.args[i].eval(c)
+    }
+    return xpValue{kind: xpNodes, nodes: []*Element{c.node}}
+  }
+
+  switch f.name {
+  case "normalize-space":
+    return xpValue{kind: xpString, s: strings.Join(strings.Fields(arg(0).str()), " ")}
+  case "string":
+    return xpValue{kind: xpString, s: arg(0).str()}
+  case "not":
+    return xpValue{kind: xpBool, b: !arg(0).boolean()}
+  case "contains":
+    return xpValue{kind: xpBool, b: strings.Contains(arg(0).str(), arg(1).str())}
+  case "starts-with":
+    return xpValue{kind: xpBool, b: strings.HasPrefix(arg(0).str(), arg(1).str())}
+  case "concat":
+    var b strings.Builder
+    for i := range f.args {
+      b.WriteString(arg(i).str())
+    }
+    return xpValue{kind: xpString, s: b.String()}
+  }
+  return xpValue{kind: xpBool}
+}
+
+type xpStep struct {
+  descendant bool   // reached by // rather than /
+  test       string // element name, *, ., .., @name or text()
+  preds      []xpNode
+}
+
+type xpPath struct {
+  absolute bool
+  steps    []xpStep
+}
+
+func (p xpPath) eval(c xpCtx) xpValue {
+  cur := []*Element{c.node}
+  if p.absolute {
+    cur = []*Element{c.doc}
+  }
+
+  for i, st := range p.steps {
+    last := i == len(p.steps)-1
+
+    if strings.HasPrefix(st.test, "@") || st.test == "text()" {
+      var strs []string
+      for _, n := range cur {
+        for _, m := range xpCandidates(n, st.descendant, true) {
+          if st.test == "text()" {
+            if m.Text != "" {
+              strs = append(strs, m.Text)
+            }
+          } else if v, ok := m.Attrs[st.test[1:]]; ok {
+            strs = append(strs, v)
+          }
+        }
+      }
+      if last {
+        return xpValue{kind: xpStrings, strs: strs}
+      }
+      return xpValue{kind: xpNodes}
+    }
+
+    var next []*Element
+    for _, n := range cur {
+      var cands []*Element
+      switch st.test {
+      case ".":
+        cands = xpCandidates(n, st.descendant, true)
+      case "..":
+        if n.Parent != nil {
+          cands = []*Element{n.Parent}
+        }
+      default:
+        for _, m := range xpCandidates(n, st.descendant, false) {
+          if st.test == "*" || m.Tag == st.test {
+            cands = append(cands, m)
+          }
+        }
+      }
+      for _, m := range cands {
+        ok := true
+        for _, pred := range st.preds {
+          if !pred.eval(xpCtx{m, c.doc}).boolean() {
+            ok = false
+            break
+          }
+        }
+        if ok {
+          next = append(next, m)
+        }
+      }
+    }
+    cur = next
+  }
+  return xpValue{kind: xpNodes, nodes: cur}
+}
+
+// xpCandidates returns the children of n, or all its descendants for //, and n itself when self is set
+func xpCandidates(n *Element, descendant, self bool) []*Element {
+  var out []*Element
+  if self {
+    out = append(out, n)
+    if !descendant {
+      return out
+    }
+  }
+  if descendant {
+    return append(out, n.descendants()...)
+  }
+  return append(out, n.Children...)
+}
+
+// parsing
+
+type xpParser struct {
+  src  string
+  toks []string
+  pos  int
+}
+
+func (p *xpParser) fail() error {
+  return errorf(StatusInvalidSelector, "invalid selector: cannot parse xpath %q", p.src)
+}
+
+func (p *xpParser) tokenize() error {
+  s := p.src
+  for i := 0; i < len(s); {
+    c := s[i]
+    switch {
+    case c == ' ' || c == '\t' || c == '\n':
+      i++
+    case strings.HasPrefix(s[i:], "//"), strings.HasPrefix(s[i:], ".."), strings.HasPrefix(s[i:], "!="):
+      p.toks = append(p.toks, s[i:i+2])
+      i += 2
+    case strings.IndexByte("/[](),=@*.", c) >= 0:
+      p.toks = append(p.toks, s[i:i+1])
+      i++
+    case c == '\'' || c == '"':
+      end := strings.IndexByte(s[i+1:], c)
+      if end < 0 {
+        return p.fail()
+      }
+      p.toks = append(p.toks, s[i:i+end+2])
+      i += end + 2
+    case c == '-' || c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z':
+      j := i
+      for j < len(s) && (s[j] == '-' || s[j] == '_' || s[j] >= '0' && s[j] <= '9' || s[j] >= 'a' && s[j] <= 'z' || s[j] >= 'A' && s[j] <= 'Z') {
+        j++
+      }
+      p.toks = append(p.toks, s[i:j])
+      i = j
+    default:
+      return p.fail()
+    }
+  }
+  return nil
+}
+
+func (p *xpParser) peek() string {
+  if p.pos < len(p.toks) {
+    return p.toks[p.pos]
+  }
+  return ""
+}
+
+func (p *xpParser) next() string {
+  t := p.peek()
+  p.pos++
+  return t
+}
+
+func (p *xpParser) expect(t string) error {
+  if p.next() != t {
+    return p.fail()
+  }
+  return nil
+}
+
+func (p *xpParser) expr() (xpNode, error) {
+  return p.binary([]string{"or", "and", "=|!="}, 0)
+}
+
+// binary parses left associative operators, levels run from loosest to tightest
+func (p *xpParser) binary(levels []string, level int) (xpNode, error) {
+  if level == len(levels) {
+    return p.primary()
+  }
+  left, err := p.binary(levels, level+1)
+  if err != nil {
+    return nil, err
+  }
+  for {
+    op := p.peek()
+    if op == "" || !strings.Contains("|"+levels[level]+"|", "|"+op+"|") {
+      return left, nil
+    }
+    p.pos++
+    right, err := p.binary(levels, level+1)
+    if err != nil {
+      return nil, err
+    }
+    left = xpBinary{op, left, right}
+  }
+}
+
+func (p *xpParser) primary() (xpNode, error) {
+  t := p.peek()
+  switch {
+  case t == "":
+    return nil, p.fail()
+  case t[0] == '\'' || t[0] == '"':
+    p.pos++
+    return xpLiteral(t[1 : len(t)-1]), nil
+  case t == "(":
+    p.pos++
+    n, err := p.expr()
+    if err != nil {
+      return nil, err
+    }
+    return n, p.expect(")")
+  case t != "text" && p.pos+1 < len(p.toks) && p.toks[p.pos+1] == "(" && isName(t):
+    p.pos += 2
+    call := xpCall{name: t}
+    for p.peek() != ")" {
+      arg, err := p.expr()
+      if err != nil {
+        return nil, err
+      }
+      call.args = append(call.args, arg)
+      if p.peek() == "," {
+        p.pos++
+      }
+    }
+    p.pos++
+    return call, nil
+  }
+  return p.path()
+}
+
+func (p *xpParser) path() (xpNode, error) {
+  var path xpPath
+  descendant := false
+
+  switch p.peek() {
+  case "/":
+    path.absolute = true
+    p.pos++
+  case "//":
+    path.absolute = true
+    descendant = true
+    p.pos++
+  }
+
+  for {
+    st, err := p.step(descendant)
+    if err != nil {
+      return nil, err
+    }
+    path.steps = append(path.steps, st)
+
+    switch p.peek() {
+    case "/":
+      descendant = false
+    case "//":
+      descendant = true
+    default:
+      return path, nil
+    }
+    p.pos++
+  }
+}
+
+func (p *xpParser) step(descendant bool) (st xpStep, err error) {
+  st.descendant = descendant
+  switch t := p.next(); {
+  case t == "." || t == ".." || t == "*":
+    st.test = t
+  case t == "@":
+    name := p.next()
+    if !isName(name) && name != "*" {
+      return st, p.fail()
+    }
+    st.test = "@" + name
+  case t == "text":
+    if err = p.expect("("); err == nil {
+      err = p.expect(")")
+    }
+    st.test = "text()"
+  case isName(t):
+    st.test = t
+  default:
+    return st, p.fail()
+  }
+
+  for err == nil && p.peek() == "[" {
+    p.pos++
+    var pred xpNode
+    if pred, err = p.expr(); err == nil {
+      st.preds = append(st.preds, pred)
+      err = p.expect("]")
+    }
+  }
+  return st, err
+}
+
+func isName(t string) bool {
+  if t == "" || t == "and" || t == "or" {
+    return false
+  }
+  c := t[0]
+  return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
@@ -0,0 +1,422 @@
+package webdriver
+
+import (
+  "fmt"
+  "github.com/sourcegraph/go-selenium"
+  "reflect"
+  "sort"
+  "strconv"
+  "strings"
+)
+
+// FillForm and ReadForm map the exported fields of a struct to the controls of
+// a form by name. The form tag renames a field or leaves it out:
+//
+//   type Profile struct {
+//     UserId    string
+//     Email     string `form:"EmailAddr"`
+//     Remember  bool   `form:"RememberMe,omitempty"`
+//     Internal  string `form:"-"`
+//   }
+//
+// Text inputs and textareas take strings and numbers, checkboxes take a bool
+// (or a []string of the checked values when several share a name), radios
+// and selects take the value of the option to choose, multiple selects a []string
+
+// FormOption changes how FillForm behaves
+type FormOption func(*formOptions)
+
+type formOptions struct {
+  skipZero bool
+}
+
+// SkipZero leaves controls alone when their field holds its zero value, as if every field were tagged omitempty
+func SkipZero() FormOption {
+  return func(o *formOptions) { o.skipZero = true }
+}
+
+// FormError lists the fields FillForm or ReadForm could not handle, the
+// others were dealt with regardless
+type FormError struct {
+  Form    string
+  Missing []string         // fields with no control of that name in the form
+  Failed  map[string]error // fields whose control could not be set or read
+}
+
+func (e *FormError) Error() string {
+  var parts []string
+  if len(e.Missing) > 0 {
+    parts = append(parts, "no control for "+strings.Join(e.Missing, ", "))
+  }
+  fields := make([]string, 0, len(e.Failed))
+  for f := range e.Failed {
+    fields = append(fields, f)
+  }
+  sort.Strings(fields)
+  for _, f := range fields {
+    parts = append(parts, fmt.Sprintf("%s: %s", f, e.Failed[f]))
+  }
+  return fmt.Sprintf("Form %s: %s", e.Form, strings.Join(parts, "; "))
+}
+
+type formField struct {
+  name      string // control name
+  field     string // struct field name
+  omitEmpty bool
+  value     reflect.Value
+}
+
+// formFields lists the fields of the struct v points to, or is
+func formFields(v reflect.Value) ([]formField, error) {
+  if v.Kind() == reflect.Ptr {
+    v = v.Elem()
+  }
+  if v.Kind() != reflect.Struct {
+    return nil, fmt.Errorf("Form values must be a struct, got %s", v.Kind())
+  }
+
+  var fields []formField
+  t := v.Type()
+  for i := 0; i < t.NumField(); i++ {
+    f := t.Field(i)
+    if f.PkgPath != "" {
+      continue
+    }
+    name, opts := f.Name, ""
+    if tag, ok := f.Tag.Lookup("form"); ok {
+      if tag == "-" {
+        continue
+      }
+      if i := strings.Index(tag, ","); i >= 0 {
+        tag, opts = tag[:i], tag[i+1:]
+      }
+      if tag != "" {
+        name = tag
+      }
+    }
+    fields = append(fields, formField{name, f.Name, opts == "omitempty", v.Field(i)})
+  }
+  return fields, nil
+}
+
+// FillForm sets the controls of the form found by form from the fields of values
+func (s *Session) FillForm(form Locator, values interface{}, opts ...FormOption) error {
+  var o formOptions
+  for _, opt := range opts {
+    opt(&o)
+  }
+
+  fields, err := formFields(reflect.ValueOf(values))
+  if err != nil {
+    return err
+  }
+  formEl, err := s.Find(form)
+  if err != nil {
+    return err
+  }
+
+  ferr := &FormError{Form: form.String(), Failed: map[string]error{}}
+  for _, f := range fields {
+    if (o.skipZero || f.omitEmpty) && f.value.IsZero() {
+      continue
+    }
+    loc := Name(f.name)
+    controls, err := formEl.FindElements(loc.By, loc.Value)
+    if err != nil || len(controls) == 0 {
+      ferr.Missing = append(ferr.Missing, f.field)
+      continue
+    }
+    if err = setControl(controls, f.value); err != nil {
+      ferr.Failed[f.field] = s.wrap("fill "+f.name+" in", &form, err)
+    }
+  }
+
+  if len(ferr.Missing) > 0 || len(ferr.Failed) > 0 {
+    return ferr
+  }
+  return nil
+}
+
+// ReadForm stores the current values of the form's controls in the struct values points to
+func (s *Session) ReadForm(form Locator, values interface{}) error {
+  v := reflect.ValueOf(values)
+  if v.Kind() != reflect.Ptr {
+    return fmt.Errorf("ReadForm needs a pointer to a struct, got %T", values)
+  }
+  fields, err := formFields(v)
+  if err != nil {
+    return err
+  }
+  formEl, err := s.Find(form)
+  if err != nil {
+    return err
+  }
+
+  ferr := &FormError{Form: form.String(), Failed: map[string]error{}}
+  for _, f := range fields {
+    loc := Name(f.name)
+    controls, err := formEl.FindElements(loc.By, loc.Value)
+    if err != nil || len(controls) == 0 {
+      ferr.Missing = append(ferr.Missing, f.field)
+      continue
+    }
+    if err = readControl(controls, f.value); err != nil {
+      ferr.Failed[f.field] = s.wrap("read "+f.name+" in", &form, err)
+    }
+  }
+
+  if len(ferr.Missing) > 0 || len(ferr.Failed) > 0 {
+    return ferr
+  }
+  return nil
+}
+
+// controlKind is the tag name, or the input type for inputs
+func controlKind(e selenium.WebElement) (string, error) {
+  tag, err := e.TagName()
+  if err != nil {
+    return "", err
+  }
+  tag = strings.ToLower(tag)
+  if tag != "input" {
+    return tag, nil
+  }
+  typ, err := e.GetAttribute("type")
+  if err != nil {
+    return "", err
+  }
+  switch typ = strings.ToLower(typ); typ {
+  case "checkbox", "radio":
+    return typ, nil
+  }
+  return "text", nil
+}
+
+// fieldStrings turns a field into the value or values to choose
+func fieldStrings(v reflect.Value) []string {
+  if v.Kind() == reflect.Slice {
+    out := make([]string, v.Len())
+    for i := range out {
+      out[i] = fmt.Sprint(v.Index(i).Interface())
+    }
+    return out
+  }
+  return []string{fmt.Sprint(v.Interface())}
+}
+
+func contains(list []string, s string) bool {
+  for _, l := range list {
+    if l == s {
+      return true
+    }
+  }
+  return false
+}
+
+func setControl(controls []selenium.WebElement, v reflect.Value) error {
+  kind, err := controlKind(controls[0])
+  if err != nil {
+    return err
+  }
+
+  switch kind {
+  case "checkbox":
+    for _, c := range controls {
+      var want bool
+      if v.Kind() == reflect.Bool {
+        want = v.Bool()
+      } else {
+        val, err := c.GetAttribute("value")
+        if err != nil {
+          return err
+        }
+        want = contains(fieldStrings(v), val)
+      }
+      if err := setSelected(c, want); err != nil {
+        return err
+      }
+    }
+    return nil
+
+  case "radio":
+    want := fieldStrings(v)[0]
+    for _, c := range controls {
+      if val, err := c.GetAttribute("value"); err != nil {
+        return err
+      } else if val == want {
+        return setSelected(c, true)
+      }
+    }
+    return fmt.Errorf("no radio button with value %q", want)
+
+  case "select":
+    return setSelect(controls[0], fieldStrings(v))
+  }
+
+  if err := controls[0].Clear(); err != nil {
+    return err
+  }
+  return controls[0].SendKeys(fieldStrings(v)[0])
+}
+
+func setSelected(e selenium.WebElement, want bool) error {
+  selected, err := e.IsSelected()
+  if err != nil || selected == want {
+    return err
+  }
+  return e.Click()
+}
+
+// setSelect picks the options whose value, or failing that text, is in want
+func setSelect(sel selenium.WebElement, want []string) error {
+  options, err := sel.FindElements(selenium.ByTagName, "option")
+  if err != nil {
+    return err
+  }
+  multiple, _ := sel.GetAttribute("multiple")
+
+  found := 0
+  for _, o := range options {
+    val, err := o.GetAttribute("value")
+    if err != nil {
+      return err
+    }
+    text, err := o.Text()
+    if err != nil {
+      return err
+    }
+    chosen := contains(want, val) || contains(want, strings.TrimSpace(text))
+    if chosen {
+      found++
+    }
+    if multiple != "" && multiple != "false" {
+      if err = setSelected(o, chosen); err != nil {
+        return err
+      }
+    } else if chosen {
+      return setSelected(o, true)
+    }
+  }
+  if found < len(want) {
+    return fmt.Errorf("no option for all of %q", want)
+  }
+  return nil
+}
+
+func readControl(controls []selenium.WebElement, v reflect.Value) error {
+  kind, err := controlKind(controls[0])
+  if err != nil {
+    return err
+  }
+
+  var vals []string
+  switch kind {
+  case "checkbox", "radio":
+    if kind == "checkbox" && v.Kind() == reflect.Bool {
+      selected, err := controls[0].IsSelected()
+      if err != nil {
+        return err
+      }
+      v.SetBool(selected)
+      return nil
+    }
+    for _, c := range controls {
+      if selected, err := c.IsSelected(); err != nil {
+        return err
+      } else if selected {
+        val, err := c.GetAttribute("value")
+        if err != nil {
+          return err
+        }
+        vals = append(vals, val)
+      }
+    }
+
+  case "select":
+    options, err := controls[0].FindElements(selenium.ByTagName, "option")
+    if err != nil {
+      return err
+    }
+    for _, o := range options {
+      if selected, err := o.IsSelected(); err != nil {
+        return err
+      } else if selected {
+        val, err := o.GetAttribute("value")
+        if err != nil {
+          return err
+        }
+        vals = append(vals, val)
+      }
+    }
+
+  default:
+    val, err := controls[0].GetAttribute("value")
+    if err != nil {
+      return err
+    }
+    vals = []string{val}
+  }
+
+  return setField(v, vals)
+}
+
+// setField stores vals in v, converting to v's type
+func setField(v reflect.Value, vals []string) error {
+  if v.Kind() == reflect.Slice {
+    slice := reflect.MakeSlice(v.Type(), len(vals), len(vals))
+    for i, s := range vals {
+      if err := setField(slice.Index(i), []string{s}); err != nil {
+        return err
+      }
+    }
+    v.Set(slice)
+    return nil
+  }
+
+  var s string
+  if len(vals) > 0 {
+    s = vals[0]
+  }
+
+  switch v.Kind() {
+  case reflect.String:
+    v.SetString(s)
+  case reflect.Bool:
+    b, err := strconv.ParseBool(s)
+    if err != nil && s != "" {
+      return err
+    }
+    v.SetBool(b)
+  case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+    n, err := strconv.ParseInt(s, 10, 64)
+    if err != nil && s != "" {
+      return err
+    }
+    v.SetInt(n)
+  case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+    n, err := strconv.ParseUint(s, 10, 64)
+    if err != nil && s != "" {
+      return err
+    }
+    v.SetUint(n)
+  case reflect.Float32, reflect.Float64:
+    f, err := strconv.ParseFloat(s, 64)
+    if err != nil && s != "" {
+      return err
+    }
+    v.SetFloat(f)
+  default:
+    return fmt.Errorf("cannot store a form value in a %s", v.Type())
+  }
+  return nil
+}
+
+// FillForm fills in a form on the default session
+func FillForm(form Locator, values interface{}, opts ...FormOption) error {
+  return DefaultSession().FillForm(form, values, opts...)
+}
+
+// ReadForm reads a form on the default session
+func ReadForm(form Locator, values interface{}) error {
+  return DefaultSession().ReadForm(form, values)
+}
@@ -0,0 +1,162 @@
+package webdriver
+
+import (
+  "code.grantmurray.com/webdriver/fakewd"
+  "errors"
+  "reflect"
+  "testing"
+)
+
+const profileURL = "https://plog.org:8004/#/profile"
+
+type testProfile struct {
+  UserId    string
+  Email     string   `form:"EmailAddr"`
+  TzName    string
+  Age       int      `form:",omitempty"`
+  Remember  bool     `form:"RememberMe"`
+  Plan      string
+  Interests []string `form:"Interest"`
+  Notes     string   `form:"-"`
+}
+
+func profilePage(srv *fakewd.Server) *fakewd.Page {
+  utc := fakewd.E("option", "value", "UTC").WithText("Coordinated Universal Time")
+  utc.Selected = true
+  return srv.Page(profileURL).Add(
+    fakewd.E("form", "name", "editProfileForm").Add(
+      fakewd.E("input", "name", "UserId", "value", "selenium-one"),
+      fakewd.E("input", "name", "EmailAddr", "type", "email"),
+      fakewd.E("select", "name", "TzName").Add(
+        utc,
+        fakewd.E("option", "value", "America/Vancouver").WithText("Pacific"),
+      ),
+      fakewd.E("input", "name", "Age"),
+      fakewd.E("input", "name", "RememberMe", "type", "checkbox"),
+      fakewd.E("input", "name", "Plan", "type", "radio", "value", "free"),
+      fakewd.E("input", "name", "Plan", "type", "radio", "value", "paid"),
+      fakewd.E("input", "name", "Interest", "type", "checkbox", "value", "birds"),
+      fakewd.E("input", "name", "Interest", "type", "checkbox", "value", "boats"),
+      fakewd.E("input", "name", "Interest", "type", "checkbox", "value", "bikes"),
+    ),
+    fakewd.E("input", "name", "Notes"),
+  )
+}
+
+var profileForm = Name("editProfileForm")
+
+func Test_FillForm(t *testing.T) {
+  s, srv := newFakeSession(t)
+  srv.W3CLocators = true
+  page := profilePage(srv)
+  s.Drv.Get(profileURL)
+
+  in := testProfile{
+    UserId:    "Selenium-Changed",
+    Email:     "bigdeal@little-planet.com",
+    TzName:    "Pacific",
+    Remember:  true,
+    Plan:      "paid",
+    Interests: []string{"birds", "bikes"},
+    Notes:     "not in the form",
+  }
+  if err := s.FillForm(profileForm, in); err != nil {
+    t.Fatalf("FillForm failed: %s", err)
+  }
+
+  for sel, want := range map[string]string{
+    `[name="UserId"]`:    "Selenium-Changed",
+    `[name="EmailAddr"]`: "bigdeal@little-planet.com",
+    `[name="Age"]`:       "",
+  } {
+    if v := page.Find(sel).Value(); v != want {
+      t.Errorf("Expected %s to hold %q, got %q", sel, want, v)
+    }
+  }
+  if !page.Find(`[name="RememberMe"]`).Selected {
+    t.Errorf("Expected RememberMe to be checked")
+  }
+  if !page.Find(`[value="paid"]`).Selected || page.Find(`[value="free"]`).Selected {
+    t.Errorf("Expected the paid plan to be chosen")
+  }
+  if !page.Find(`[value="America/Vancouver"]`).Selected || page.Find(`[value="UTC"]`).Selected {
+    t.Errorf("Expected the Pacific time zone to be selected")
+  }
+
+  var out testProfile
+  if err := s.ReadForm(profileForm, &out); err != nil {
+    t.Fatalf("ReadForm failed: %s", err)
+  }
+  in.TzName, in.Notes = "America/Vancouver", ""
+  if !reflect.DeepEqual(out, in) {
+    t.Errorf("Expected to read back %+v, got %+v", in, out)
+  }
+}
+
+func Test_FillForm_SkipZero(t *testing.T) {
+  s, srv := newFakeSession(t)
+  srv.W3CLocators = true
+  page := profilePage(srv)
+  s.Drv.Get(profileURL)
+
+  if err := s.FillForm(profileForm, testProfile{Email: "georgek@mailbot.net"}, SkipZero()); err != nil {
+    t.Fatalf("FillForm failed: %s", err)
+  }
+  if v := page.Find(`[name="UserId"]`).Value(); v != "selenium-one" {
+    t.Errorf("Expected UserId to be left alone, got %q", v)
+  }
+  if v := page.Find(`[name="EmailAddr"]`).Value(); v != "georgek@mailbot.net" {
+    t.Errorf("Expected EmailAddr to be filled, got %q", v)
+  }
+  if !page.Find(`[value="UTC"]`).Selected {
+    t.Errorf("Expected the time zone to be left alone")
+  }
+}
+
+func Test_FillForm_missing(t *testing.T) {
+  s, srv := newFakeSession(t)
+  srv.W3CLocators = true
+  profilePage(srv)
+  s.Drv.Get(profileURL)
+
+  type withExtras struct {
+    UserId   string
+    Nickname string
+    Notes    string
+    Plan     string
+  }
+  err := s.FillForm(profileForm, withExtras{UserId: "x", Nickname: "y", Notes: "z", Plan: "gold"})
+
+  var ferr *FormError
+  if !errors.As(err, &ferr) {
+    t.Fatalf("Expected a *FormError, got %v", err)
+  }
+  if !reflect.DeepEqual(ferr.Missing, []string{"Nickname", "Notes"}) {
+    t.Errorf("Expected Nickname and Notes (outside the form) to be missing, got %q", ferr.Missing)
+  }
+  if _, ok := ferr.Failed["Plan"]; !ok || len(ferr.Failed) != 1 {
+    t.Errorf("Expected only Plan to fail, got %v", ferr.Failed)
+  }
+  if v := srv.Page(profileURL).Find(`[name="UserId"]`).Value(); v != "x" {
+    t.Errorf("Expected the fields found to be filled anyway, UserId is %q", v)
+  }
+
+  if err = s.ReadForm(profileForm, withExtras{}); err == nil {
+    t.Errorf("Expected ReadForm to refuse a struct that is not a pointer")
+  }
+  if err = s.FillForm(Name("noSuchForm"), withExtras{}); !errors.Is(err, ErrNoSuchElement) {
+    t.Errorf("Expected ErrNoSuchElement for a missing form, got %v", err)
+  }
+}
+
+func Test_FormError_order(t *testing.T) {
+  e := &FormError{Form: "profile", Missing: []string{"Notes"}, Failed: map[string]error{
+    "Plan": errors.New("no option gold"), "Age": errors.New("not a number"), "Email": errors.New("disabled"),
+  }}
+  want := "Form profile: no control for Notes; Age: not a number; Email: disabled; Plan: no option gold"
+  for i := 0; i < 5; i++ {
+    if got := e.Error(); got != want {
+      t.Fatalf("Expected %q, got %q", want, got)
+    }
+  }
+}
@@ -0,0 +1,232 @@
+package webdriver
+
+import (
+  "encoding/base64"
+  "encoding/json"
+  "io/ioutil"
+  "mime"
+  "net/http"
+  "net/url"
+  "strconv"
+  "strings"
+  "time"
+)
+
+// HAR is an HTTP Archive 1.2 document, see http://www.softwareishard.com/blog/har-12-spec/
+// Only the parts the recording proxy fills in are modelled
+type HAR struct {
+  Log HARLog `json:"log"`
+}
+
+// HARLog holds the recorded entries
+type HARLog struct {
+  Version string     `json:"version"`
+  Creator HARCreator `json:"creator"`
+  Entries []HAREntry `json:"entries"`
+}
+
+// HARCreator names the program that wrote the HAR
+type HARCreator struct {
+  Name    string `json:"name"`
+  Version string `json:"version"`
+}
+
+// HAREntry is one request and its response
+type HAREntry struct {
+  StartedDateTime time.Time   `json:"startedDateTime"`
+  Time            float64     `json:"time"` // total milliseconds, the sum of the timings
+  Request         HARRequest  `json:"request"`
+  Response        HARResponse `json:"response"`
+  Cache           struct{}    `json:"cache"`
+  Timings         HARTimings  `json:"timings"`
+  ServerIPAddress string      `json:"serverIPAddress,omitempty"`
+  Comment         string      `json:"comment,omitempty"`
+}
+
+// HARRequest is a recorded request, sizes are in bytes and -1 when unknown
+type HARRequest struct {
+  Method      string         `json:"method"`
+  URL         string         `json:"url"`
+  HTTPVersion string         `json:"httpVersion"`
+  Cookies     []HARCookie    `json:"cookies"`
+  Headers     []HARNameValue `json:"headers"`
+  QueryString []HARNameValue `json:"queryString"`
+  PostData    *HARPostData   `json:"postData,omitempty"`
+  HeadersSize int            `json:"headersSize"`
+  BodySize    int            `json:"bodySize"`
+}
+
+// HARResponse is a recorded response
+type HARResponse struct {
+  Status      int            `json:"status"`
+  StatusText  string         `json:"statusText"`
+  HTTPVersion string         `json:"httpVersion"`
+  Cookies     []HARCookie    `json:"cookies"`
+  Headers     []HARNameValue `json:"headers"`
+  Content     HARContent     `json:"content"`
+  RedirectURL string         `json:"redirectURL"`
+  HeadersSize int            `json:"headersSize"`
+  BodySize    int            `json:"bodySize"`
+}
+
+// HARNameValue is a header, query parameter or form field
+type HARNameValue struct {
+  Name  string `json:"name"`
+  Value string `json:"value"`
+}
+
+// HARCookie is a cookie sent with a request or set by a response
+type HARCookie struct {
+  Name     string     `json:"name"`
+  Value    string     `json:"value"`
+  Path     string     `json:"path,omitempty"`
+  Domain   string     `json:"domain,omitempty"`
+  Expires  *time.Time `json:"expires,omitempty"`
+  HTTPOnly bool       `json:"httpOnly,omitempty"`
+  Secure   bool       `json:"secure,omitempty"`
+}
+
+// HARPostData is a recorded request body
+type HARPostData struct {
+  MimeType string         `json:"mimeType"`
+  Params   []HARNameValue `json:"params,omitempty"`
+  Text     string         `json:"text"`
+}
+
+// HARContent is a recorded response body
+type HARContent struct {
+  Size     int    `json:"size"`
+  MimeType string `json:"mimeType"`
+  Text     string `json:"text,omitempty"`
+  Encoding string `json:"encoding,omitempty"` // base64 for binary bodies
+}
+
+// HARTimings are in milliseconds, -1 where a phase did not happen
+type HARTimings struct {
+  Blocked float64 `json:"blocked"`
+  DNS     float64 `json:"dns"`
+  Connect float64 `json:"connect"`
+  Send    float64 `json:"send"`
+  Wait    float64 `json:"wait"`
+  Receive float64 `json:"receive"`
+  SSL     float64 `json:"ssl"`
+}
+
+// NewHAR wraps entries in a HAR document
+func NewHAR(entries []HAREntry) *HAR {
+  if entries == nil {
+    entries = []HAREntry{}
+  }
+  return &HAR{HARLog{Version: "1.2", Creator: HARCreator{"code.grantmurray.com/webdriver", "1"}, Entries: entries}}
+}
+
+// WriteFile saves the HAR as indented JSON
+func (h *HAR) WriteFile(filename string) error {
+  data, err := json.MarshalIndent(h, "", "  ")
+  if err != nil {
+    return err
+  }
+  return ioutil.WriteFile(filename, data, 0644)
+}
+
+func harHeaders(h http.Header) []HARNameValue {
+  list := []HARNameValue{}
+  for name, values := range h {
+    for _, v := range values {
+      list = append(list, HARNameValue{name, v})
+    }
+  }
+  return list
+}
+
+func harQuery(u *url.URL) []HARNameValue {
+  list := []HARNameValue{}
+  for name, values := range u.Query() {
+    for _, v := range values {
+      list = append(list, HARNameValue{name, v})
+    }
+  }
+  return list
+}
+
+func harRequestCookies(r *http.Request) []HARCookie {
+  list := []HARCookie{}
+  for _, c := range r.Cookies() {
+    list = append(list, HARCookie{Name: c.Name, Value: c.Value})
+  }
+  return list
+}
+
+func harResponseCookies(r *http.Response) []HARCookie {
+  list := []HARCookie{}
+  for _, c := range r.Cookies() {
+    hc := HARCookie{Name: c.Name, Value: c.Value, Path: c.Path, Domain: c.Domain, HTTPOnly: c.HttpOnly, Secure: c.Secure}
+    if !c.Expires.IsZero() {
+      expires := c.Expires
+      hc.Expires = &expires
+    }
+    list = append(list, hc)
+  }
+  return list
+}
+
+// harRequest records r, whose body has already been read into body
+func harRequest(r *http.Request, body []byte) HARRequest {
+  hr := HARRequest{
+    Method:      r.Method,
+    URL:         r.URL.String(),
+    HTTPVersion: r.Proto,
+    Cookies:     harRequestCookies(r),
+    Headers:     harHeaders(r.Header),
+    QueryString: harQuery(r.URL),
+    HeadersSize: -1,
+    BodySize:    len(body),
+  }
+  if len(body) > 0 {
+    hr.PostData = &HARPostData{MimeType: r.Header.Get("Content-Type"), Text: string(body)}
+    if mt, _, _ := mime.ParseMediaType(hr.PostData.MimeType); mt == "application/x-www-form-urlencoded" {
+      if values, err := url.ParseQuery(string(body)); err == nil {
+        for name, vs := range values {
+          for _, v := range vs {
+            hr.PostData.Params = append(hr.PostData.Params, HARNameValue{name, v})
+          }
+        }
+      }
+    }
+  }
+  return hr
+}
+
+// harResponse records resp, whose body has already been read into body
+func harResponse(resp *http.Response, body []byte) HARResponse {
+  hr := HARResponse{
+    Status:      resp.StatusCode,
+    StatusText:  strings.TrimSpace(strings.TrimPrefix(resp.Status, strconv.Itoa(resp.StatusCode))),
+    HTTPVersion: resp.Proto,
+    Cookies:     harResponseCookies(resp),
+    Headers:     harHeaders(resp.Header),
+    RedirectURL: resp.Header.Get("Location"),
+    HeadersSize: -1,
+    BodySize:    len(body),
+    Content:     HARContent{Size: len(body), MimeType: resp.Header.Get("Content-Type")},
+  }
+  if textual(hr.Content.MimeType) {
+    hr.Content.Text = string(body)
+  } else if len(body) > 0 {
+    hr.Content.Text = base64.StdEncoding.EncodeToString(body)
+    hr.Content.Encoding = "base64"
+  }
+  return hr
+}
+
+// textual reports whether a body of mimeType can go into the HAR as it is
+func textual(mimeType string) bool {
+  mt, _, _ := mime.ParseMediaType(mimeType)
+  return strings.HasPrefix(mt, "text/") || strings.HasSuffix(mt, "json") || strings.HasSuffix(mt, "xml") ||
+    strings.HasSuffix(mt, "javascript") || mt == "application/x-www-form-urlencoded"
+}
+
+// millis converts a duration to HAR milliseconds
+func millis(d time.Duration) float64 {
+  return float64(d) / float64(time.Millisecond)
+}
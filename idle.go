@@ -0,0 +1,89 @@
+package webdriver
+
+import (
+  "fmt"
+  "strings"
+  "time"
+)
+
+// networkIdleScript wraps XMLHttpRequest and fetch to keep track of the
+// requests in flight in window.__webdriverNet, unless that is already done,
+// and returns [requests in flight, milliseconds since the last one started
+// or finished]. A new document loses the instrumentation, so every check
+// puts it back; requests made before that are not seen, which is why
+// installing counts as activity
+const networkIdleScript = `var w = window, net = w.__webdriverNet;
+if (!net) {
+  net = w.__webdriverNet = {pending: {}, next: 0, last: Date.now()};
+  var start = function(desc) {
+    var id = ++net.next;
+    net.pending[id] = desc;
+    net.last = Date.now();
+    return id;
+  };
+  var done = function(id) {
+    if (net.pending[id]) {
+      delete net.pending[id];
+      net.last = Date.now();
+    }
+  };
+  if (w.XMLHttpRequest) {
+    var proto = w.XMLHttpRequest.prototype, open = proto.open, send = proto.send;
+    proto.open = function(method, url) {
+      this.__webdriverDesc = method + " " + url;
+      return open.apply(this, arguments);
+    };
+    proto.send = function() {
+      var id = start(this.__webdriverDesc || "XMLHttpRequest");
+      this.addEventListener("loadend", function() { done(id); });
+      try {
+        return send.apply(this, arguments);
+      } catch (e) {
+        done(id);
+        throw e;
+      }
+    };
+  }
+  if (w.fetch) {
+    var fetch = w.fetch;
+    w.fetch = function(input, init) {
+      var url = typeof input == "string" ? input : input && input.url;
+      var method = init && init.method || input && input.method || "GET";
+      var id = start(method + " " + url);
+      return fetch.apply(w, arguments).then(
+        function(r) { done(id); return r; },
+        function(e) { done(id); throw e; });
+    };
+  }
+}
+var pending = [];
+for (var id in net.pending) { pending.push(net.pending[id]); }
+return [pending, Date.now() - net.last];`
+
+// NetworkIdle is done once the page has had no XMLHttpRequest or fetch in
+// flight for quiet. The first check instruments the page, and so does the
+// first check after a navigation, which means the page has to stay quiet
+// for that long after the check before it counts as idle
+func NetworkIdle(quiet time.Duration) Condition {
+  return condition{fmt.Sprintf("no network requests for %s", quiet), func(s *Session) (bool, string, error) {
+    var result []interface{}
+    if err := s.script(networkIdleScript, nil, &result); err != nil {
+      return false, "", err
+    }
+    if len(result) != 2 {
+      return false, "", fmt.Errorf("Unexpected result from the network check: %v", result)
+    }
+    list, _ := result[0].([]interface{})
+    ms, _ := result[1].(float64)
+    since := time.Duration(ms) * time.Millisecond
+
+    if len(list) > 0 {
+      pending := make([]string, len(list))
+      for i, p := range list {
+        pending[i] = fmt.Sprint(p)
+      }
+      return false, fmt.Sprintf("%d requests in flight: %s", len(pending), strings.Join(pending, ", ")), nil
+    }
+    return since >= quiet, fmt.Sprintf("last request activity %s ago", since), nil
+  }}
+}
@@ -0,0 +1,39 @@
+package webdriver
+
+import (
+  "code.grantmurray.com/webdriver/fakewd"
+  "context"
+  "errors"
+  "testing"
+  "time"
+)
+
+func Test_NetworkIdle(t *testing.T) {
+  s, srv := newFakeSession(t)
+  s.PollInterval = time.Millisecond
+
+  calls := 0
+  srv.HandleScript("__webdriverNet", func(b *fakewd.Browser, args []interface{}) (interface{}, error) {
+    calls++
+    switch {
+    case calls <= 2:
+      return []interface{}{[]string{"GET /api/album"}, 0}, nil
+    case calls <= 4:
+      return []interface{}{[]string{}, 100}, nil // quiet, but not for long enough
+    }
+    return []interface{}{[]string{}, 600}, nil
+  })
+  if err := s.WaitUntil(context.Background(), NetworkIdle(500*time.Millisecond)); err != nil || calls != 5 {
+    t.Errorf("Expected idle on the fifth check, got %v after %d", err, calls)
+  }
+
+  srv.HandleScript("__webdriverNet", func(b *fakewd.Browser, args []interface{}) (interface{}, error) {
+    return []interface{}{[]string{"POST /api/login"}, 3000}, nil
+  })
+  ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+  defer cancel()
+  var te *TimeoutError
+  if err := s.WaitUntil(ctx, NetworkIdle(time.Second)); !errors.As(err, &te) || te.Observed != "1 requests in flight: POST /api/login" {
+    t.Errorf("Expected a timeout naming the request in flight, got %v", err)
+  }
+}
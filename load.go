@@ -0,0 +1,151 @@
+package webdriver
+
+import (
+  "context"
+  "fmt"
+  "strconv"
+  "time"
+)
+
+// Page load strategies, see WithPageLoadStrategy
+const (
+  PageLoadNormal = "normal" // the driver returns from Get once the page and its resources are loaded
+  PageLoadEager  = "eager"  // once the document is parsed, before images and the like
+  PageLoadNone   = "none"   // straight away
+)
+
+// readyStates orders the values of document.readyState
+var readyStates = map[string]int{"loading": 0, "interactive": 1, "complete": 2}
+
+// readyState is the document.readyState that the session's page load strategy waits for, "" for none
+func (s *Session) readyState() string {
+  switch s.Config.PageLoad {
+  case PageLoadNone:
+    return ""
+  case PageLoadEager:
+    return "interactive"
+  }
+  return "complete"
+}
+
+// DocumentReady is done once document.readyState has reached state:
+// "interactive" once the document is parsed, "complete" once it is loaded
+func DocumentReady(state string) Condition {
+  return condition{"document to be " + state, func(s *Session) (bool, string, error) {
+    var got string
+    if err := s.script("return document.readyState", nil, &got); err != nil {
+      return false, "", err
+    }
+    return readyStates[got] >= readyStates[state], "readyState " + got, nil
+  }}
+}
+
+// Get loads url and waits for the document to be ready as far as the page
+// load strategy asks: complete for normal, interactive for eager and not at
+// all for none. Drivers do not always wait themselves, e.g. after a redirect.
+// For single page apps see Navigate
+func (s *Session) Get(url string) error {
+  return s.keepConsole(func() error {
+    if err := s.Drv.Get(url); err != nil {
+      return s.wrap("open "+url, nil, err)
+    }
+    if state := s.readyState(); state != "" {
+      return s.WaitUntil(context.Background(), DocumentReady(state))
+    }
+    return nil
+  })
+}
+
+// navigationScript labels the current document with a token and notes hash
+// changes, so that afterwards the document can be told apart from its successor
+const navigationScript = `var w = window;
+w.__webdriverNavigation = arguments[0];
+w.__webdriverHashChanged = false;
+w.addEventListener("hashchange", function() { w.__webdriverHashChanged = true; });`
+
+// navigatedScript returns [still the labelled document, its hash changed, location, readyState]
+const navigatedScript = `var w = window;
+return [w.__webdriverNavigation === arguments[0], !!w.__webdriverHashChanged, location.href, document.readyState];`
+
+// navigated is done once the document labelled with token has been replaced
+// and the new one is ready, or the labelled one has moved to another route
+func navigated(token, url, state string) Condition {
+  return condition{"navigation to " + url, func(s *Session) (bool, string, error) {
+    var result []interface{}
+    if err := s.script(navigatedScript, []interface{}{token}, &result); err != nil {
+      return false, "", err
+    }
+    if len(result) != 4 {
+      return false, "", fmt.Errorf("Unexpected result from the navigation check: %v", result)
+    }
+    same, _ := result[0].(bool)
+    hashChanged, _ := result[1].(bool)
+    href, _ := result[2].(string)
+    ready, _ := result[3].(string)
+
+    switch {
+    case !same:
+      return readyStates[ready] >= readyStates[state], fmt.Sprintf("new document at %s, readyState %s", href, ready), nil
+    case hashChanged || href == url:
+      return true, "route changed to " + href, nil
+    }
+    return false, "still on " + href, nil
+  }}
+}
+
+// Navigate goes to url and returns once what it leads to is loaded. That is
+// a new document that is ready as far as the page load strategy asks (see
+// Get), or in a single page app a new route of the same document, e.g.
+// from #/login to #/album. After that it waits for the session's Sync
+// condition and BusyMarker, so the route's data has arrived too. Going to
+// the url the browser is already on reloads the page
+func (s *Session) Navigate(url string) error {
+  cur, err := s.Drv.CurrentURL()
+  if err != nil {
+    return s.wrap("get current url", nil, err)
+  }
+  if cur == url {
+    err = s.keepConsole(func() error {
+      if err := s.Drv.Refresh(); err != nil {
+        return s.wrap("reload "+url, nil, err)
+      }
+      if state := s.readyState(); state != "" {
+        return s.WaitUntil(context.Background(), DocumentReady(state))
+      }
+      return nil
+    })
+  } else {
+    err = s.keepConsole(func() error {
+      token := strconv.FormatInt(time.Now().UnixNano(), 36)
+      if err := s.script(navigationScript, []interface{}{token}, new(interface{})); err != nil {
+        return fmt.Errorf("Cannot prepare navigation to %s: %w", url, err)
+      }
+      if err := s.Drv.Get(url); err != nil {
+        return s.wrap("open "+url, nil, err)
+      }
+      state := s.readyState()
+      if state == "" {
+        state = "loading"
+      }
+      return s.WaitUntil(context.Background(), navigated(token, url, state))
+    })
+  }
+  if err != nil {
+    return err
+  }
+
+  if err = s.sync(); err != nil {
+    return fmt.Errorf("Page not settled after navigating to %s: %w", url, err)
+  }
+  return s.WaitNotBusy()
+}
+
+// Get loads url on the default session and waits for the document
+func Get(url string) error {
+  return DefaultSession().Get(url)
+}
+
+// Navigate goes to url on the default session, see Session.Navigate
+func Navigate(url string) error {
+  return DefaultSession().Navigate(url)
+}
@@ -0,0 +1,83 @@
+package webdriver
+
+import (
+  "code.grantmurray.com/webdriver/fakewd"
+  "errors"
+  "strings"
+  "testing"
+  "time"
+)
+
+const albumURL = "https://plog.org:8004/#/album"
+
+func Test_DocumentIsReady(t *testing.T) {
+  s, srv := newFakeSession(t)
+  loginPage(srv)
+  s.Drv.Get(loginURL)
+
+  if !s.DocumentIsReady(nil) {
+    t.Errorf("Expected a complete document to be ready")
+  }
+  srv.Page(loginURL).ReadyState = "loading"
+  if s.DocumentIsReady(nil) {
+    t.Errorf("Expected a loading document not to be ready")
+  }
+}
+
+func Test_Get_page_load_strategy(t *testing.T) {
+  s, srv := newFakeSession(t)
+  s.PollInterval = time.Millisecond
+  s.WaitTimeout = 20 * time.Millisecond
+  loginPage(srv).ReadyState = "interactive"
+
+  if err := s.Get(loginURL); !errors.Is(err, ErrTimeout) {
+    t.Errorf("Expected normal loading to wait for complete, got %v", err)
+  }
+  s.Config.PageLoad = PageLoadEager
+  if err := s.Get(loginURL); err != nil {
+    t.Errorf("Expected eager loading to settle for interactive, got %v", err)
+  }
+  srv.Page(loginURL).ReadyState = "loading"
+  s.Config.PageLoad = PageLoadNone
+  if err := s.Get(loginURL); err != nil {
+    t.Errorf("Expected no waiting at all, got %v", err)
+  }
+}
+
+func Test_Navigate(t *testing.T) {
+  s, srv := newFakeSession(t)
+  s.PollInterval = time.Millisecond
+  s.WaitTimeout = 20 * time.Millisecond
+  loginPage(srv)
+  b := srv.Sessions()[0]
+
+  if err := s.Navigate(loginURL); err != nil || b.URL != loginURL {
+    t.Fatalf("Cannot navigate to the login page: %v", err)
+  }
+  doc := b.Document
+
+  // a hash route keeps the document
+  if err := s.Navigate(albumURL); err != nil || b.URL != albumURL || b.Document != doc {
+    t.Errorf("Expected a route change in the same document, got %v at %s in document %d", err, b.URL, b.Document)
+  }
+
+  // the same url again reloads
+  if err := s.Navigate(albumURL); err != nil || b.Document != doc+1 {
+    t.Errorf("Expected a reload, got %v in document %d", err, b.Document)
+  }
+
+  // a new document has to finish loading
+  srv.Page("https://example.com/").ReadyState = "loading"
+  err := s.Navigate("https://example.com/")
+  var te *TimeoutError
+  if !errors.As(err, &te) || !strings.Contains(te.Observed, "readyState loading") {
+    t.Errorf("Expected to time out on the loading document, got %v", err)
+  }
+
+  // and the application must not be busy
+  srv.Page(loginURL).Add(fakewd.E("div", "class", "selenium-flag"))
+  s.Busy = &BusyMarker{Locator: CSS("div.selenium-flag")}
+  if err = s.Navigate(loginURL); !errors.Is(err, ErrTimeout) || !strings.Contains(err.Error(), "still busy") {
+    t.Errorf("Expected to time out on the busy marker, got %v", err)
+  }
+}
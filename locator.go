@@ -0,0 +1,205 @@
+package webdriver
+
+import (
+  "fmt"
+  "github.com/sourcegraph/go-selenium"
+  "strings"
+)
+
+// Locator says how to find an element: By is one of the selenium.By*
+// strategies and Value is the query for it. Build them with the constructors
+// below, they take care of quoting
+type Locator struct {
+  By    string
+  Value string
+  desc  string // what the caller asked for, used in messages
+}
+
+// CSS finds elements with a CSS selector
+func CSS(selector string) Locator {
+  return Locator{selenium.ByCSSSelector, selector, "css " + selector}
+}
+
+// XPath finds elements with an XPath expression
+func XPath(expr string) Locator {
+  return Locator{selenium.ByXPATH, expr, "xpath " + expr}
+}
+
+// ID finds the element with the given id attribute. Like Name it is a CSS
+// attribute selector, W3C drivers dropped the id and name strategies
+func ID(id string) Locator {
+  return Locator{selenium.ByCSSSelector, "[id=" + cssString(id) + "]", fmt.Sprintf("id %q", id)}
+}
+
+// Name finds elements by their name attribute
+func Name(name string) Locator {
+  return Locator{selenium.ByCSSSelector, "[name=" + cssString(name) + "]", fmt.Sprintf("name %q", name)}
+}
+
+// LinkText finds links whose whole text is text
+func LinkText(text string) Locator {
+  return Locator{selenium.ByLinkText, text, fmt.Sprintf("link text %q", text)}
+}
+
+// PartialLinkText finds links whose text contains text
+func PartialLinkText(text string) Locator {
+  return Locator{selenium.ByPartialLinkText, text, fmt.Sprintf("partial link text %q", text)}
+}
+
+// Text finds the innermost elements whose visible text, with whitespace
+// normalized, is exactly text
+func Text(text string) Locator {
+  lit := xpathLiteral(text)
+  expr := fmt.Sprintf("//*[normalize-space(.)=%s and not(.//*[normalize-space(.)=%s])]", lit, lit)
+  return Locator{selenium.ByXPATH, expr, fmt.Sprintf("text %q", text)}
+}
+
+// LabelFor finds the form control that a <label for=...> with the given text points at
+func LabelFor(label string) Locator {
+  expr := fmt.Sprintf("//*[@id=//label[normalize-space(.)=%s]/@for]", xpathLiteral(label))
+  return Locator{selenium.ByXPATH, expr, fmt.Sprintf("label %q", label)}
+}
+
+// AttrIs finds elements whose attribute name is exactly value, e.g. AttrIs("name", "LoginMessage")
+func AttrIs(name, value string) Locator {
+  return Locator{selenium.ByCSSSelector, fmt.Sprintf("[%s=%s]", name, cssString(value)), fmt.Sprintf("%s %q", name, value)}
+}
+
+func (l Locator) String() string {
+  if l.desc != "" {
+    return l.desc
+  }
+  return l.By + " " + l.Value
+}
+
+// xpathLiteral quotes s for use in an XPath expression. XPath 1.0 has no
+// escapes so a string holding both kinds of quote is built with concat()
+func xpathLiteral(s string) string {
+  if !strings.Contains(s, "'") {
+    return "'" + s + "'"
+  }
+  if !strings.Contains(s, `"`) {
+    return `"` + s + `"`
+  }
+  parts := strings.Split(s, "'")
+  for i, p := range parts {
+    parts[i] = "'" + p + "'"
+  }
+  return "concat(" + strings.Join(parts, `, "'", `) + ")"
+}
+
+// cssString quotes s as a CSS string
+func cssString(s string) string {
+  r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\a `)
+  return `"` + r.Replace(s) + `"`
+}
+
+// Find returns the first element matching loc
+func (s *Session) Find(loc Locator) (selenium.WebElement, error) {
+  if err := s.sync(); err != nil {
+    return nil, fmt.Errorf("Page not settled before find %s: %w", loc, err)
+  }
+  e, err := s.Drv.FindElement(loc.By, loc.Value)
+  return e, s.wrap("find", &loc, err)
+}
+
+// FindAll returns every element matching loc
+func (s *Session) FindAll(loc Locator) ([]selenium.WebElement, error) {
+  if err := s.sync(); err != nil {
+    return nil, fmt.Errorf("Page not settled before find all %s: %w", loc, err)
+  }
+  es, err := s.Drv.FindElements(loc.By, loc.Value)
+  return es, s.wrap("find all", &loc, err)
+}
+
+// FindElementsBy returns a map holding the element found for each entry in locs
+func (s *Session) FindElementsBy(locs map[string]Locator) (elements map[string]selenium.WebElement, err error) {
+  elements = make(map[string]selenium.WebElement, len(locs))
+
+  for k, loc := range locs {
+    elements[k], err = s.Find(loc)
+    if err != nil {
+      return elements, fmt.Errorf("Error finding element %s: %w", k, err)
+    }
+  }
+  return elements, nil
+}
+
+// FetchTextBy returns the text of the element matching loc
+func (s *Session) FetchTextBy(loc Locator) (msg string, err error) {
+  e, err := s.Find(loc)
+  if err != nil {
+    return "", err
+  }
+  msg, err = e.Text()
+  if err != nil {
+    return "", s.wrap("retrieve text of", &loc, err)
+  }
+  return msg, nil
+}
+
+// locatorArg lets the []interface{} style WaitFor functions take either a
+// CSS selector string or a Locator
+func locatorArg(args []interface{}) (Locator, error) {
+  if len(args) == 0 {
+    return Locator{}, fmt.Errorf("Missing locator argument")
+  }
+  switch a := args[0].(type) {
+  case string:
+    return CSS(a), nil
+  case Locator:
+    return a, nil
+  }
+  return Locator{}, fmt.Errorf("Expected a selector string or Locator, got %T", args[0])
+}
+
+// Find returns the first element matching loc on the default session
+func Find(loc Locator) (selenium.WebElement, error) {
+  return DefaultSession().Find(loc)
+}
+
+// FindAll returns every element matching loc on the default session
+func FindAll(loc Locator) ([]selenium.WebElement, error) {
+  return DefaultSession().FindAll(loc)
+}
+
+// FindElementsBy looks up each of locs on the default session
+func FindElementsBy(locs map[string]Locator) (map[string]selenium.WebElement, error) {
+  return DefaultSession().FindElementsBy(locs)
+}
+
+// FetchTextBy returns the text of the element matching loc on the default session
+func FetchTextBy(loc Locator) (string, error) {
+  return DefaultSession().FetchTextBy(loc)
+}
+
+// ParseLocator reads the strategy=value form used in page object tags, e.g.
+// "name=UserIdentifier", "css=form[name=loginForm]" or "label=Email address".
+// The strategies are css, xpath, id, name, link, partial, text and label
+func ParseLocator(spec string) (Locator, error) {
+  i := strings.Index(spec, "=")
+  if i < 0 {
+    return Locator{}, fmt.Errorf("Locator %q is not of the form strategy=value", spec)
+  }
+  value := spec[i+1:]
+
+  switch spec[:i] {
+  case "css":
+    return CSS(value), nil
+  case "xpath":
+    return XPath(value), nil
+  case "id":
+    return ID(value), nil
+  case "name":
+    return Name(value), nil
+  case "link":
+    return LinkText(value), nil
+  case "partial":
+    return PartialLinkText(value), nil
+  case "text":
+    return Text(value), nil
+  case "label":
+    return LabelFor(value), nil
+  }
+  return Locator{}, fmt.Errorf("Locator %q has unknown strategy %q", spec, spec[:i])
+}
@@ -0,0 +1,83 @@
+package webdriver
+
+import (
+  "code.grantmurray.com/webdriver/fakewd"
+  "github.com/sourcegraph/go-selenium"
+  "testing"
+)
+
+func Test_xpathLiteral(t *testing.T) {
+  cases := map[string]string{
+    `plain`:             `'plain'`,
+    `Don't`:             `"Don't"`,
+    `Don't say "hi"`:    `concat('Don', "'", 't say "hi"')`,
+    `'both' and "both"`: `concat('', "'", 'both', "'", ' and "both"')`,
+  }
+  for in, want := range cases {
+    if got := xpathLiteral(in); got != want {
+      t.Errorf("xpathLiteral(%s): expected %s, got %s", in, want, got)
+    }
+  }
+}
+
+func Test_Locators(t *testing.T) {
+  s, srv := newFakeSession(t)
+  srv.W3CLocators = true
+  srv.Page(loginURL).Add(
+    fakewd.E("form", "name", "loginForm").Add(
+      fakewd.E("label", "for", "uid").WithText("User Id"),
+      fakewd.E("input", "id", "uid", "name", `Say "hi"`),
+      fakewd.E("label", "for", "pw").WithText(`Don't say "password"`),
+      fakewd.E("input", "id", "pw", "name", "ClearPassword"),
+    ),
+    fakewd.E("p", "name", "Message").Add(fakewd.E("b").WithText("Save successful")),
+    fakewd.E("a", "href", "#/logout").WithText("Logout now"),
+  )
+  s.Drv.Get(loginURL)
+
+  cases := []struct {
+    loc  Locator
+    want string // expected name attribute, or tag when there is none
+  }{
+    {CSS(`form > input#pw`), "ClearPassword"},
+    {ID("uid"), `Say "hi"`},
+    {Name(`Say "hi"`), `Say "hi"`},
+    {AttrIs("name", `Say "hi"`), `Say "hi"`},
+    {LabelFor("User Id"), `Say "hi"`},
+    {LabelFor(`Don't say "password"`), "ClearPassword"},
+    {Text("Save successful"), "b"},
+    {LinkText("Logout now"), "a"},
+    {PartialLinkText("Logout"), "a"},
+    {XPath(`//p[@name='Message']`), "Message"},
+  }
+
+  for _, c := range cases {
+    if c.loc.By == selenium.ById || c.loc.By == selenium.ByName {
+      t.Errorf("%s: uses the legacy %s strategy", c.loc, c.loc.By)
+    }
+    e, err := s.Find(c.loc)
+    if err != nil {
+      t.Errorf("%s: %s", c.loc, err)
+      continue
+    }
+    got, _ := e.GetAttribute("name")
+    if got == "" {
+      got, _ = e.TagName()
+    }
+    if got != c.want {
+      t.Errorf("%s: expected %s, got %s", c.loc, c.want, got)
+    }
+  }
+
+  elements, err := s.FindNamedElements([]string{`Say "hi"`, "ClearPassword"})
+  if err != nil || len(elements) != 2 {
+    t.Errorf("FindNamedElements with a quoted name failed: %v", err)
+  }
+
+  if !s.ElementToAppear([]interface{}{LabelFor("User Id")}) {
+    t.Errorf("ElementToAppear should accept a Locator")
+  }
+  if s.ElementToAppear([]interface{}{42}) {
+    t.Errorf("ElementToAppear should reject a bad argument rather than panic")
+  }
+}
@@ -0,0 +1,158 @@
+package webdriver
+
+import (
+  "errors"
+  "fmt"
+  "strings"
+  "testing"
+  "time"
+)
+
+// LogEntry is one line of a browser log
+type LogEntry struct {
+  Timestamp int64  `json:"timestamp"` // milliseconds since the epoch
+  Level     string `json:"level"`     // SEVERE, WARNING, INFO, DEBUG...
+  Message   string `json:"message"`
+}
+
+// Time returns the entry's timestamp as a time.Time
+func (e LogEntry) Time() time.Time {
+  return time.Unix(0, e.Timestamp*int64(time.Millisecond))
+}
+
+func (e LogEntry) String() string {
+  return fmt.Sprintf("%s %-7s %s", e.Time().Format("15:04:05.000"), e.Level, e.Message)
+}
+
+// BrowserLog fetches the browser's console log from the server. Drivers
+// hand out each entry once, so a second call only returns what was logged since
+func (s *Session) BrowserLog() (entries []LogEntry, err error) {
+  err = s.command("POST", "/log", map[string]string{"type": "browser"}, &entries)
+  return entries, err
+}
+
+// consoleHookScript wraps the console methods and listens for uncaught errors
+// and rejections, keeping what it sees in window.__webdriverConsole. Running
+// it again hands over and clears what was collected; a page load loses the hook
+// and anything collected since the last run
+const consoleHookScript = `var w = window;
+if (!w.__webdriverConsole) {
+  w.__webdriverConsole = [];
+  var push = function(level, args) {
+    var parts = [];
+    for (var i = 0; i < args.length; i++) {
+      var a = args[i];
+      if (a instanceof Error) {
+        a = a.stack || String(a);
+      } else if (a !== null && typeof a == "object") {
+        try { a = JSON.stringify(a); } catch (e) { a = String(a); }
+      }
+      parts.push(String(a));
+    }
+    w.__webdriverConsole.push({timestamp: Date.now(), level: level, message: parts.join(" ")});
+  };
+  var levels = {debug: "DEBUG", log: "INFO", info: "INFO", warn: "WARNING", error: "SEVERE"};
+  Object.keys(levels).forEach(function(name) {
+    var orig = console[name];
+    console[name] = function() {
+      push(levels[name], arguments);
+      if (orig) { return orig.apply(console, arguments); }
+    };
+  });
+  w.addEventListener("error", function(ev) {
+    push("SEVERE", ["Uncaught " + (ev.error && ev.error.stack || ev.message) + " (" + ev.filename + ":" + ev.lineno + ")"]);
+  });
+  w.addEventListener("unhandledrejection", function(ev) {
+    push("SEVERE", ["Uncaught (in promise) " + (ev.reason && ev.reason.stack || ev.reason)]);
+  });
+}
+var out = w.__webdriverConsole;
+w.__webdriverConsole = [];
+return out;`
+
+// Console collects what the browser has logged since the last call and
+// returns everything collected in this session so far. It reads the
+// server's browser log, and where the server has none installs a hook in the
+// page instead. The hook only sees what happens after it is installed, so
+// call Console (or FailOnJSErrors) once a page has loaded to start it. Get,
+// Navigate, Open and State keep it going across the pages they load
+func (s *Session) Console() ([]LogEntry, error) {
+  var entries []LogEntry
+  var err error
+  if !s.consoleHook {
+    entries, err = s.BrowserLog()
+    if errors.Is(err, ErrUnknownCommand) {
+      s.consoleHook = true
+    }
+  }
+  if s.consoleHook {
+    err = s.script(consoleHookScript, nil, &entries)
+  }
+  s.console = append(s.console, entries...)
+  return s.console, err
+}
+
+// keepConsole runs load, which leaves the page, so that the console hook
+// does not lose what it collected: it takes that in before and puts the hook
+// back in the new page after. Without the hook it just runs load. Console
+// errors are left for the next Console call to report
+func (s *Session) keepConsole(load func() error) error {
+  if !s.consoleHook {
+    return load()
+  }
+  s.Console()
+  err := load()
+  s.Console()
+  return err
+}
+
+// JSErrors returns the SEVERE entries among entries. That covers uncaught
+// exceptions, console.error, which is where AngularJS reports exceptions,
+// and resources that failed to load
+func JSErrors(entries []LogEntry) []LogEntry {
+  var errs []LogEntry
+  for _, e := range entries {
+    if strings.EqualFold(e.Level, "SEVERE") {
+      errs = append(errs, e)
+    }
+  }
+  return errs
+}
+
+// FailOnJSErrors makes t fail if the browser reports a JavaScript error (see
+// JSErrors) between now and the end of the test
+func (s *Session) FailOnJSErrors(t testing.TB) {
+  before, err := s.Console()
+  if err != nil {
+    t.Logf("Cannot collect the browser console: %s", err)
+  }
+  start := len(before)
+
+  t.Cleanup(func() {
+    all, err := s.Console()
+    if err != nil {
+      t.Errorf("Cannot collect the browser console: %s", err)
+    }
+    if start > len(all) {
+      return
+    }
+    for _, e := range JSErrors(all[start:]) {
+      t.Errorf("JavaScript error: %s", e)
+    }
+  })
+}
+
+// BrowserLog fetches the console log of the default session
+func BrowserLog() ([]LogEntry, error) {
+  return DefaultSession().BrowserLog()
+}
+
+// Console collects the console of the default session
+func Console() ([]LogEntry, error) {
+  return DefaultSession().Console()
+}
+
+// FailOnJSErrors fails t on JavaScript errors in the default session
+func FailOnJSErrors(t testing.TB) {
+  DefaultSession().FailOnJSErrors(t)
+}
@@ -0,0 +1,105 @@
+package webdriver
+
+import (
+  "code.grantmurray.com/webdriver/fakewd"
+  "strings"
+  "testing"
+)
+
+func Test_Console(t *testing.T) {
+  s, srv := newFakeSession(t)
+  loginPage(srv)
+  s.Drv.Get(loginURL)
+  b := srv.Sessions()[0]
+
+  b.Console("INFO", "loading")
+  entries, err := s.Console()
+  if err != nil || len(entries) != 1 {
+    t.Fatalf("Expected one entry, got %v (%v)", entries, err)
+  }
+
+  tb := &recordingTB{TB: t, name: "Test_Login"}
+  s.FailOnJSErrors(tb)
+  b.Console("WARNING", "deprecated")
+  tb.finish()
+  if tb.failed {
+    t.Errorf("Expected a warning not to fail the test, got %q", tb.logs)
+  }
+
+  tb = &recordingTB{TB: t, name: "Test_Login"}
+  s.FailOnJSErrors(tb)
+  b.Console("SEVERE", "Error: [$injector:unpr] Unknown provider")
+  tb.finish()
+  if !tb.failed || len(tb.logs) != 1 || !strings.Contains(tb.logs[0], "Unknown provider") {
+    t.Errorf("Expected the angular error to fail the test, got %q", tb.logs)
+  }
+
+  if entries, _ = s.Console(); len(entries) != 3 {
+    t.Errorf("Expected the session to keep all 3 entries, got %v", entries)
+  }
+}
+
+func Test_Console_hook(t *testing.T) {
+  s, srv := newFakeSession(t)
+  loginPage(srv)
+  srv.NoBrowserLog = true
+  s.Drv.Get(loginURL)
+
+  var pending []fakewd.LogEntry
+  srv.HandleScript("__webdriverConsole", func(b *fakewd.Browser, args []interface{}) (interface{}, error) {
+    out := pending
+    pending = []fakewd.LogEntry{}
+    return out, nil
+  })
+
+  tb := &recordingTB{TB: t, name: "Test_Login"}
+  s.FailOnJSErrors(tb)
+  pending = append(pending, fakewd.LogEntry{Timestamp: 1500000000000, Level: "SEVERE", Message: "Uncaught TypeError: x is undefined"})
+  tb.finish()
+
+  if !tb.failed {
+    t.Errorf("Expected the uncaught error seen by the hook to fail the test")
+  }
+  if !s.consoleHook {
+    t.Errorf("Expected the session to switch to the hook")
+  }
+  entries, _ := s.Console()
+  if len(entries) != 1 || entries[0].Time().Year() != 2017 {
+    t.Errorf("Expected the hook's entry with its timestamp, got %v", entries)
+  }
+}
+
+func Test_Console_hook_across_navigation(t *testing.T) {
+  s, srv := newFakeSession(t)
+  loginPage(srv)
+  srv.Page("https://example.com/")
+  srv.NoBrowserLog = true
+  s.Drv.Get(loginURL)
+  b := srv.Sessions()[0]
+
+  // like the real hook, this one only sees what is logged in the document it was installed in
+  srv.HandleScript("__webdriverConsole", func(b *fakewd.Browser, args []interface{}) (interface{}, error) {
+    if b.Globals["consoleHook"] != true {
+      b.Globals["consoleHook"] = true
+      b.Log = nil
+    }
+    out := append([]fakewd.LogEntry{}, b.Log...)
+    b.Log = nil
+    return out, nil
+  })
+
+  tb := &recordingTB{TB: t, name: "Test_Album"}
+  s.FailOnJSErrors(tb)
+  b.Console("SEVERE", "Error: [$injector:unpr] Unknown provider")
+  if err := s.Navigate("https://example.com/"); err != nil {
+    t.Fatalf("Cannot navigate: %s", err)
+  }
+  tb.finish()
+
+  if !tb.failed {
+    t.Errorf("Expected the error logged before navigating to fail the test")
+  }
+  if b.Globals["consoleHook"] != true {
+    t.Errorf("Expected the hook to be back in the new document")
+  }
+}
@@ -0,0 +1,155 @@
+package webdriver
+
+import (
+  "context"
+  "fmt"
+  "math/rand"
+  "testing"
+  "time"
+)
+
+// NetworkConditions describe the connection the browser is to have. The
+// zero value is an unthrottled connection
+type NetworkConditions struct {
+  Offline    bool
+  Latency    time.Duration // added to every request
+  Download   int           // bytes per second, 0 for unlimited
+  Upload     int           // bytes per second, 0 for unlimited
+  PacketLoss float64       // fraction of requests that are lost, 0 to 1. Only the recording proxy can emulate this
+}
+
+// Throttling profiles, the numbers are those of Chrome's developer tools
+var (
+  NetworkOffline   = NetworkConditions{Offline: true}
+  NetworkGPRS      = NetworkConditions{Latency: 500 * time.Millisecond, Download: 50 * 1024 / 8, Upload: 20 * 1024 / 8}
+  NetworkRegular2G = NetworkConditions{Latency: 300 * time.Millisecond, Download: 250 * 1024 / 8, Upload: 50 * 1024 / 8}
+  NetworkRegular3G = NetworkConditions{Latency: 100 * time.Millisecond, Download: 750 * 1024 / 8, Upload: 250 * 1024 / 8}
+  NetworkGood3G    = NetworkConditions{Latency: 40 * time.Millisecond, Download: 1536 * 1024 / 8, Upload: 750 * 1024 / 8}
+  NetworkRegular4G = NetworkConditions{Latency: 20 * time.Millisecond, Download: 4096 * 1024 / 8, Upload: 3072 * 1024 / 8}
+  NetworkWiFi      = NetworkConditions{Latency: 2 * time.Millisecond, Download: 30720 * 1024 / 8, Upload: 15360 * 1024 / 8}
+  NetworkFlaky     = NetworkConditions{Latency: 40 * time.Millisecond, Download: 1536 * 1024 / 8, Upload: 750 * 1024 / 8, PacketLoss: 0.1}
+)
+
+var (
+  errOffline    = fmt.Errorf("network offline: %w", errAborted)
+  errPacketLost = fmt.Errorf("packet lost: %w", errAborted)
+)
+
+// transfer is how long n bytes take at rate bytes per second
+func transfer(n, rate int) time.Duration {
+  if rate <= 0 || n <= 0 {
+    return 0
+  }
+  return time.Duration(n) * time.Second / time.Duration(rate)
+}
+
+// pause sleeps for d unless ctx ends first
+func pause(ctx context.Context, d time.Duration) error {
+  if d <= 0 {
+    return nil
+  }
+  select {
+  case <-time.After(d):
+    return nil
+  case <-ctx.Done():
+    return ctx.Err()
+  }
+}
+
+// sending applies the conditions to a request with n bytes of body before it goes out
+func (nc NetworkConditions) sending(ctx context.Context, n int) error {
+  if nc.Offline {
+    return errOffline
+  }
+  if nc.PacketLoss > 0 && rand.Float64() < nc.PacketLoss {
+    return errPacketLost
+  }
+  return pause(ctx, nc.Latency+transfer(n, nc.Upload))
+}
+
+// receiving applies the conditions to a response with n bytes of body
+func (nc NetworkConditions) receiving(ctx context.Context, n int) error {
+  return pause(ctx, transfer(n, nc.Download))
+}
+
+// SetNetwork makes requests from now on go through the proxy as if over
+// conditions: offline and lost requests have their connection dropped, the
+// others are held back for the latency and the time their bodies would take
+func (p *Proxy) SetNetwork(conditions NetworkConditions) {
+  p.mu.Lock()
+  defer p.mu.Unlock()
+  p.network = conditions
+}
+
+// Network returns the conditions the proxy currently emulates
+func (p *Proxy) Network() NetworkConditions {
+  p.mu.Lock()
+  defer p.mu.Unlock()
+  return p.network
+}
+
+// SetNetwork emulates conditions for the browser until changed again, so a
+// test can go offline halfway through. With a recording proxy (see
+// WithRecordingProxy) the proxy does it, otherwise Chrome's network
+// conditions command, which cannot emulate PacketLoss
+func (s *Session) SetNetwork(conditions NetworkConditions) error {
+  if s.Proxy != nil {
+    s.Proxy.SetNetwork(conditions)
+    return nil
+  }
+  if conditions.PacketLoss > 0 {
+    return fmt.Errorf("Cannot emulate packet loss: %w", ErrNoProxy)
+  }
+
+  throughput := func(rate int) int {
+    if rate <= 0 {
+      return -1 // no throttling
+    }
+    return rate
+  }
+  params := map[string]interface{}{
+    "network_conditions": map[string]interface{}{
+      "offline":             conditions.Offline,
+      "latency":             conditions.Latency.Milliseconds(),
+      "download_throughput": throughput(conditions.Download),
+      "upload_throughput":   throughput(conditions.Upload),
+    },
+  }
+  return s.command("POST", "/chromium/network_conditions", params, nil)
+}
+
+// ResetNetwork stops emulating network conditions
+func (s *Session) ResetNetwork() error {
+  if s.Proxy != nil {
+    s.Proxy.SetNetwork(NetworkConditions{})
+    return nil
+  }
+  return s.command("DELETE", "/chromium/network_conditions", nil, nil)
+}
+
+// NetworkFor emulates conditions until t finishes or SetNetwork changes them
+func (s *Session) NetworkFor(t testing.TB, conditions NetworkConditions) {
+  if err := s.SetNetwork(conditions); err != nil {
+    t.Fatalf("Cannot set the network conditions: %s", err)
+  }
+  t.Cleanup(func() {
+    if err := s.ResetNetwork(); err != nil {
+      t.Logf("Cannot reset the network conditions: %s", err)
+    }
+  })
+}
+
+// SetNetwork emulates conditions in the default session
+func SetNetwork(conditions NetworkConditions) error {
+  return DefaultSession().SetNetwork(conditions)
+}
+
+// ResetNetwork stops emulating network conditions in the default session
+func ResetNetwork() error {
+  return DefaultSession().ResetNetwork()
+}
+
+// NetworkFor emulates conditions in the default session for the duration of t
+func NetworkFor(t testing.TB, conditions NetworkConditions) {
+  DefaultSession().NetworkFor(t, conditions)
+}
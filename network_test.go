@@ -0,0 +1,87 @@
+package webdriver
+
+import (
+  "errors"
+  "strings"
+  "testing"
+  "time"
+)
+
+func Test_Proxy_SetNetwork(t *testing.T) {
+  p, client := startProxy(t)
+  srv := backend(t, false)
+
+  p.SetNetwork(NetworkOffline)
+  if _, _, err := get(client, "GET", srv.URL+"/login"); err == nil {
+    t.Errorf("Expected no connection while offline")
+  }
+  if e := p.Entries(); len(e) != 1 || e[0].Comment != errOffline.Error() {
+    t.Errorf("Expected the lost request to be recorded, got %+v", e)
+  }
+
+  p.SetNetwork(NetworkConditions{Latency: 50 * time.Millisecond})
+  start := time.Now()
+  if status, _, err := get(client, "GET", srv.URL+"/login"); err != nil || status != 200 {
+    t.Errorf("Expected the server's answer once back online, got %d %v", status, err)
+  }
+  if d := time.Since(start); d < 50*time.Millisecond {
+    t.Errorf("Expected the latency to be added, took %s", d)
+  }
+
+  p.Route("", "*/big", Stub{Status: 200, Body: []byte(strings.Repeat("x", 1000))})
+  p.SetNetwork(NetworkConditions{Download: 10000})
+  start = time.Now()
+  if _, body, err := get(client, "GET", srv.URL+"/big"); err != nil || len(body) != 1000 {
+    t.Errorf("Expected the whole body, got %d bytes %v", len(body), err)
+  }
+  if d := time.Since(start); d < 100*time.Millisecond {
+    t.Errorf("Expected 1000 bytes at 10000 bytes/s to take 100ms, took %s", d)
+  }
+
+  // a browser giving up while the response is held back is still recorded
+  p.Reset()
+  p.SetNetwork(NetworkConditions{Download: 1})
+  impatient := *client
+  impatient.Timeout = 50 * time.Millisecond
+  if _, _, err := get(&impatient, "GET", srv.URL+"/login"); err == nil {
+    t.Errorf("Expected the throttled response to time out")
+  }
+  deadline := time.Now().Add(time.Second)
+  for len(p.Entries()) == 0 && time.Now().Before(deadline) {
+    time.Sleep(5 * time.Millisecond)
+  }
+  if e := p.Entries(); len(e) != 1 || e[0].Response.Status != 0 || e[0].Comment == "" {
+    t.Errorf("Expected the dropped exchange to be recorded with its error, got %+v", e)
+  }
+
+  p.SetNetwork(NetworkConditions{PacketLoss: 1})
+  if _, _, err := get(client, "GET", srv.URL+"/login"); err == nil {
+    t.Errorf("Expected the request to be lost")
+  }
+}
+
+func Test_SetNetwork_chrome(t *testing.T) {
+  s, srv := newFakeSession(t)
+  b := srv.Sessions()[0]
+
+  if err := s.SetNetwork(NetworkRegular3G); err != nil {
+    t.Fatalf("Cannot set the network conditions: %s", err)
+  }
+  nc := b.NetworkConditions
+  if nc["offline"] != false || nc["latency"] != 100.0 || nc["download_throughput"] != float64(750*1024/8) {
+    t.Errorf("Expected the Regular 3G profile, got %v", nc)
+  }
+  if err := s.SetNetwork(NetworkFlaky); !errors.Is(err, ErrNoProxy) {
+    t.Errorf("Expected packet loss to need the proxy, got %v", err)
+  }
+
+  tb := &recordingTB{TB: t, name: "Test_Offline"}
+  s.NetworkFor(tb, NetworkOffline)
+  if b.NetworkConditions["offline"] != true {
+    t.Errorf("Expected to be offline, got %v", b.NetworkConditions)
+  }
+  tb.finish()
+  if b.NetworkConditions != nil {
+    t.Errorf("Expected the conditions to be reset after the test, got %v", b.NetworkConditions)
+  }
+}
@@ -0,0 +1,294 @@
+package webdriver
+
+import (
+  "encoding/json"
+  "flag"
+  "fmt"
+  "github.com/sourcegraph/go-selenium"
+  "os"
+  "path/filepath"
+  "strconv"
+)
+
+// Config says where the remote end lives and what kind of browser to ask it for.
+// Values are layered: built in defaults, then WEBDRIVER_* environment
+// variables, then -webdriver.* flags, then any Options passed in code
+type Config struct {
+  URL          string
+  Browser      string
+  Version      string
+  Platform     string
+  Proxy        string                 // host:port used for both http and https traffic
+  PageLoad     string                 // page load strategy: PageLoadNormal, PageLoadEager or PageLoadNone, "" for the driver's default
+  Capabilities map[string]interface{} // extra capabilities, these win over everything above
+
+  // Server, when set to one of the server kinds, makes NewRemoteSession launch
+  // that server from ServerPath and use it instead of URL
+  Server     string
+  ServerPath string
+  ServerLog  string // defaults to webdriver.<kind>.log in the temp directory
+
+  ArtifactDir string // where CaptureOnFailure writes, defaults to webdriver-artifacts in the temp directory
+
+  // MatchesBaseline compares screenshots with the images in BaselineDir,
+  // when UpdateBaselines is set it replaces them instead
+  BaselineDir     string // defaults to testdata/baselines
+  UpdateBaselines bool
+
+  // Record puts a recording Proxy between the browser and the network, see RecordHAR
+  Record bool
+
+  // Sync becomes Session.Sync, waited for before every find
+  Sync Condition
+  // Busy becomes Session.Busy
+  Busy *BusyMarker
+}
+
+// Option changes one aspect of a Config
+type Option func(*Config)
+
+// WithURL sets the url of the selenium hub or driver
+func WithURL(url string) Option {
+  return func(c *Config) { c.URL = url }
+}
+
+// WithBrowser sets the browserName capability, e.g. chrome or firefox
+func WithBrowser(name string) Option {
+  return func(c *Config) { c.Browser = name }
+}
+
+// WithVersion sets the browser version capability
+func WithVersion(version string) Option {
+  return func(c *Config) { c.Version = version }
+}
+
+// WithPlatform sets the platform capability, e.g. LINUX or WINDOWS
+func WithPlatform(platform string) Option {
+  return func(c *Config) { c.Platform = platform }
+}
+
+// WithProxy makes the browser send its traffic through the proxy at hostport
+func WithProxy(hostport string) Option {
+  return func(c *Config) { c.Proxy = hostport }
+}
+
+// WithPageLoadStrategy sets how long the driver's Get blocks, and what
+// Session.Get and Navigate wait for: PageLoadNormal, PageLoadEager or PageLoadNone
+func WithPageLoadStrategy(strategy string) Option {
+  return func(c *Config) { c.PageLoad = strategy }
+}
+
+// WithCapability adds an arbitrary capability to the new session request
+func WithCapability(name string, value interface{}) Option {
+  return func(c *Config) {
+    if c.Capabilities == nil {
+      c.Capabilities = make(map[string]interface{})
+    }
+    c.Capabilities[name] = value
+  }
+}
+
+// WithLocalServer makes the session launch and own a local server of kind
+// (SeleniumServer, ChromeDriver or GeckoDriver) found at path
+func WithLocalServer(kind, path string) Option {
+  return func(c *Config) {
+    c.Server = kind
+    c.ServerPath = path
+  }
+}
+
+// WithServerLog sets the file the local server's output is written to
+func WithServerLog(filename string) Option {
+  return func(c *Config) { c.ServerLog = filename }
+}
+
+// WithBaselines sets the directory of baseline screenshots and whether to
+// update them rather than compare against them
+func WithBaselines(dir string, update bool) Option {
+  return func(c *Config) { c.BaselineDir, c.UpdateBaselines = dir, update }
+}
+
+// WithRecordingProxy makes the session start a recording Proxy and send the
+// browser's traffic through it. A proxy set with WithProxy is used upstream of it
+func WithRecordingProxy() Option {
+  return func(c *Config) { c.Record = true }
+}
+
+// WithSync makes the session wait for cond before every find, and so before
+// every click or other action on a page object Element
+func WithSync(cond Condition) Option {
+  return func(c *Config) { c.Sync = cond }
+}
+
+// WithAngularSync makes the session wait for AngularStable before every find
+func WithAngularSync() Option {
+  return WithSync(AngularStable())
+}
+
+// WithAngularSyncIn makes the session wait for AngularStableIn(rootSelector) before every find
+func WithAngularSyncIn(rootSelector string) Option {
+  return WithSync(AngularStableIn(rootSelector))
+}
+
+// WithBusyMarker tells the session how the application shows it is busy,
+// see BusyMarker
+func WithBusyMarker(m BusyMarker) Option {
+  return func(c *Config) { c.Busy = &m }
+}
+
+// WithArtifactDir sets where CaptureOnFailure keeps what it collects
+func WithArtifactDir(dir string) Option {
+  return func(c *Config) { c.ArtifactDir = dir }
+}
+
+// defineFlags adds the webdriver flags to fs. They are defined on the
+// command line flags, so go test picks them up, e.g. go test -webdriver.browser=firefox
+func defineFlags(fs *flag.FlagSet) *flag.FlagSet {
+  fs.String("webdriver.url", "", "selenium hub or driver url (env WEBDRIVER_URL)")
+  fs.String("webdriver.browser", "", "browser name (env WEBDRIVER_BROWSER)")
+  fs.String("webdriver.version", "", "browser version (env WEBDRIVER_VERSION)")
+  fs.String("webdriver.platform", "", "browser platform (env WEBDRIVER_PLATFORM)")
+  fs.String("webdriver.proxy", "", "host:port of a proxy for the browser (env WEBDRIVER_PROXY)")
+  fs.String("webdriver.caps", "", "extra capabilities as a JSON object (env WEBDRIVER_CAPS)")
+  fs.String("webdriver.pageload", "", "page load strategy: normal, eager or none (env WEBDRIVER_PAGE_LOAD)")
+  fs.String("webdriver.server", "", "launch a local selenium, chromedriver or geckodriver (env WEBDRIVER_SERVER)")
+  fs.String("webdriver.server.path", "", "jar or binary for -webdriver.server (env WEBDRIVER_SERVER_PATH)")
+  fs.String("webdriver.server.log", "", "log file for -webdriver.server (env WEBDRIVER_SERVER_LOG)")
+  fs.String("webdriver.artifacts", "", "directory for failed test artifacts (env WEBDRIVER_ARTIFACTS)")
+  fs.String("webdriver.baselines", "", "directory of baseline screenshots (env WEBDRIVER_BASELINES)")
+  fs.Bool("webdriver.update-baselines", false, "save screenshots as the new baselines instead of comparing (env WEBDRIVER_UPDATE_BASELINES)")
+  fs.Bool("webdriver.record", false, "record browser traffic through a local proxy (env WEBDRIVER_RECORD)")
+  return fs
+}
+
+func init() {
+  defineFlags(flag.CommandLine)
+}
+
+// NewConfig builds a Config from the defaults, the environment, the flags and finally opts
+func NewConfig(opts ...Option) (Config, error) {
+  return newConfig(flag.CommandLine, opts...)
+}
+
+// newConfig is NewConfig reading the flags from fs
+func newConfig(fs *flag.FlagSet, opts ...Option) (c Config, err error) {
+  c = Config{URL: RemoteURL, Browser: "chrome"}
+  get := func(name string) string {
+    if f := fs.Lookup(name); f != nil {
+      return f.Value.String()
+    }
+    return ""
+  }
+
+  layers := []struct {
+    url, browser, version, platform, proxy, caps, server, srvPath, srvLog, artifacts, baselines, pageLoad string
+  }{
+    {os.Getenv("WEBDRIVER_URL"), os.Getenv("WEBDRIVER_BROWSER"), os.Getenv("WEBDRIVER_VERSION"),
+      os.Getenv("WEBDRIVER_PLATFORM"), os.Getenv("WEBDRIVER_PROXY"), os.Getenv("WEBDRIVER_CAPS"),
+      os.Getenv("WEBDRIVER_SERVER"), os.Getenv("WEBDRIVER_SERVER_PATH"), os.Getenv("WEBDRIVER_SERVER_LOG"),
+      os.Getenv("WEBDRIVER_ARTIFACTS"), os.Getenv("WEBDRIVER_BASELINES"), os.Getenv("WEBDRIVER_PAGE_LOAD")},
+    {get("webdriver.url"), get("webdriver.browser"), get("webdriver.version"), get("webdriver.platform"),
+      get("webdriver.proxy"), get("webdriver.caps"), get("webdriver.server"), get("webdriver.server.path"),
+      get("webdriver.server.log"), get("webdriver.artifacts"), get("webdriver.baselines"), get("webdriver.pageload")},
+  }
+
+  for _, l := range layers {
+    setIf(&c.URL, l.url)
+    setIf(&c.Browser, l.browser)
+    setIf(&c.Version, l.version)
+    setIf(&c.Platform, l.platform)
+    setIf(&c.Proxy, l.proxy)
+    setIf(&c.Server, l.server)
+    setIf(&c.ServerPath, l.srvPath)
+    setIf(&c.ServerLog, l.srvLog)
+    setIf(&c.ArtifactDir, l.artifacts)
+    setIf(&c.BaselineDir, l.baselines)
+    setIf(&c.PageLoad, l.pageLoad)
+    if l.caps != "" {
+      var extra map[string]interface{}
+      if err = json.Unmarshal([]byte(l.caps), &extra); err != nil {
+        return c, fmt.Errorf("Cannot parse extra capabilities %s: %w", l.caps, err)
+      }
+      for k, v := range extra {
+        WithCapability(k, v)(&c)
+      }
+    }
+  }
+
+  // a bool flag only counts when given, so -webdriver.record=false can turn off WEBDRIVER_RECORD=1
+  given := map[string]bool{}
+  fs.Visit(func(f *flag.Flag) { given[f.Name] = true })
+  bools := []struct {
+    dst  *bool
+    env  string
+    name string
+  }{
+    {&c.UpdateBaselines, "WEBDRIVER_UPDATE_BASELINES", "webdriver.update-baselines"},
+    {&c.Record, "WEBDRIVER_RECORD", "webdriver.record"},
+  }
+  for _, b := range bools {
+    if env := os.Getenv(b.env); env != "" {
+      if *b.dst, err = strconv.ParseBool(env); err != nil {
+        return c, fmt.Errorf("Cannot parse %s: %w", b.env, err)
+      }
+    }
+    if given[b.name] {
+      *b.dst = get(b.name) == "true"
+    }
+  }
+
+  for _, opt := range opts {
+    opt(&c)
+  }
+
+  switch c.PageLoad {
+  case "", PageLoadNormal, PageLoadEager, PageLoadNone:
+  default:
+    return c, fmt.Errorf("Unknown page load strategy %q, use normal, eager or none", c.PageLoad)
+  }
+  if c.Server != "" && c.ServerLog == "" {
+    c.ServerLog = filepath.Join(os.TempDir(), "webdriver."+c.Server+".log")
+  }
+  if c.BaselineDir == "" {
+    c.BaselineDir = filepath.Join("testdata", "baselines")
+  }
+  if c.ArtifactDir == "" {
+    c.ArtifactDir = filepath.Join(os.TempDir(), "webdriver-artifacts")
+  }
+  return c, nil
+}
+
+func setIf(dst *string, val string) {
+  if val != "" {
+    *dst = val
+  }
+}
+
+// SeleniumCapabilities returns the desired capabilities for a new session request
+func (c Config) SeleniumCapabilities() selenium.Capabilities {
+  caps := selenium.Capabilities(map[string]interface{}{"browserName": c.Browser})
+  if c.Version != "" {
+    caps["version"] = c.Version
+  }
+  if c.Platform != "" {
+    caps["platform"] = c.Platform
+  }
+  if c.Proxy != "" {
+    caps["proxy"] = proxyCapability(c.Proxy)
+  }
+  if c.PageLoad != "" {
+    caps["pageLoadStrategy"] = c.PageLoad
+  }
+  for k, v := range c.Capabilities {
+    caps[k] = v
+  }
+  return caps
+}
+
+func proxyCapability(hostport string) map[string]interface{} {
+  return map[string]interface{}{
+    "proxyType": "manual",
+    "httpProxy": hostport,
+    "sslProxy":  hostport,
+  }
+}
@@ -0,0 +1,66 @@
+package webdriver
+
+import (
+  "flag"
+  "testing"
+)
+
+func Test_NewConfig_layers(t *testing.T) {
+  t.Setenv("WEBDRIVER_BROWSER", "firefox")
+  t.Setenv("WEBDRIVER_CAPS", `{"acceptSslCerts": true}`)
+
+  c, err := NewConfig(WithVersion("31"), WithProxy("localhost:8080"), WithCapability("platform", "ANY"))
+  if err != nil {
+    t.Fatalf("NewConfig failed: %s", err)
+  }
+
+  if c.URL != RemoteURL {
+    t.Errorf("Expected default url %s, got %s", RemoteURL, c.URL)
+  }
+
+  caps := c.SeleniumCapabilities()
+  expect := map[string]interface{}{"browserName": "firefox", "version": "31", "platform": "ANY", "acceptSslCerts": true}
+  for k, v := range expect {
+    if caps[k] != v {
+      t.Errorf("Expected capability %s to be %v, got %v", k, v, caps[k])
+    }
+  }
+
+  proxy, ok := caps["proxy"].(map[string]interface{})
+  if !ok || proxy["httpProxy"] != "localhost:8080" || proxy["sslProxy"] != "localhost:8080" {
+    t.Errorf("Proxy capability not set as expected: %v", caps["proxy"])
+  }
+}
+
+func Test_NewConfig_bad_caps(t *testing.T) {
+  t.Setenv("WEBDRIVER_CAPS", `{not json`)
+
+  if _, err := NewConfig(); err == nil {
+    t.Fatalf("Expected an error for malformed WEBDRIVER_CAPS")
+  }
+}
+
+func Test_NewConfig_page_load(t *testing.T) {
+  c, err := NewConfig(WithPageLoadStrategy(PageLoadEager))
+  if err != nil || c.SeleniumCapabilities()["pageLoadStrategy"] != "eager" {
+    t.Errorf("Expected the eager page load strategy, got %v %v", c.SeleniumCapabilities(), err)
+  }
+  if _, err = NewConfig(WithPageLoadStrategy("fast")); err == nil {
+    t.Errorf("Expected an unknown page load strategy to be refused")
+  }
+}
+
+func Test_NewConfig_bool_flag_overrides_env(t *testing.T) {
+  t.Setenv("WEBDRIVER_RECORD", "1")
+  fs := defineFlags(flag.NewFlagSet("test", flag.ContinueOnError))
+  if c, err := newConfig(fs); err != nil || !c.Record {
+    t.Errorf("Expected WEBDRIVER_RECORD to turn recording on, got %v (%v)", c.Record, err)
+  }
+  if err := fs.Parse([]string{"-webdriver.record=false", "-webdriver.browser=firefox"}); err != nil {
+    t.Fatal(err)
+  }
+  c, err := newConfig(fs)
+  if err != nil || c.Record || c.Browser != "firefox" {
+    t.Errorf("Expected -webdriver.record=false to turn it off again, got %v %s (%v)", c.Record, c.Browser, err)
+  }
+}
@@ -0,0 +1,210 @@
+package webdriver
+
+import (
+  "context"
+  "errors"
+  "fmt"
+  "github.com/sourcegraph/go-selenium"
+  "reflect"
+)
+
+// Page is implemented by page objects. A page object is a struct whose
+// *Element fields carry a wd tag saying how to find them, e.g.
+//
+//   type LoginPage struct {
+//     webdriver.PageBase
+//     UserIdentifier *webdriver.Element `wd:"name=UserIdentifier"`
+//     LoginButton    *webdriver.Element `wd:"name=LoginButton"`
+//   }
+//
+// Navigation methods on a page object finish with On (or Open) for the page
+// they lead to and return it
+type Page interface {
+  // URL is where the page can be loaded from, "" if it cannot be loaded directly
+  URL() string
+  // Ready is what must hold before the page can be used
+  Ready() Condition
+}
+
+// PageBase can be embedded in page objects to give them their Session
+type PageBase struct {
+  session *Session
+}
+
+// Session returns the session the page was bound to
+func (p *PageBase) Session() *Session {
+  return p.session
+}
+
+func (p *PageBase) bindSession(s *Session) {
+  p.session = s
+}
+
+type sessionBinder interface {
+  bindSession(s *Session)
+}
+
+// Element is an element of a page object. It is looked up afresh each time it
+// is used, so it survives the page re-rendering underneath it
+type Element struct {
+  Loc Locator
+  s   *Session
+}
+
+// NewElement returns a lazily resolved element for loc
+func (s *Session) NewElement(loc Locator) *Element {
+  return &Element{Loc: loc, s: s}
+}
+
+// Get finds the element now
+func (e *Element) Get() (selenium.WebElement, error) {
+  return e.s.Find(e.Loc)
+}
+
+// Exists reports whether the element can be found right now
+func (e *Element) Exists() bool {
+  _, err := e.Get()
+  return err == nil
+}
+
+// do finds the element and runs fn on it, trying once more if the element
+// went stale in between
+func (e *Element) do(op string, fn func(we selenium.WebElement) error) error {
+  var err error
+  for try := 0; try < 2; try++ {
+    var we selenium.WebElement
+    if we, err = e.Get(); err != nil {
+      return err
+    }
+    if err = e.s.wrap(op, &e.Loc, fn(we)); !errors.Is(err, ErrStaleElement) {
+      return err
+    }
+  }
+  return err
+}
+
+// act is do for actions that can make the application busy, it waits for
+// the session's BusyMarker afterwards
+func (e *Element) act(op string, fn func(we selenium.WebElement) error) error {
+  if err := e.do(op, fn); err != nil {
+    return err
+  }
+  return e.s.settle()
+}
+
+// Click clicks the element
+func (e *Element) Click() error {
+  return e.act("click", func(we selenium.WebElement) error { return we.Click() })
+}
+
+// Clear empties a text input
+func (e *Element) Clear() error {
+  return e.act("clear", func(we selenium.WebElement) error { return we.Clear() })
+}
+
+// SendKeys types keys into the element
+func (e *Element) SendKeys(keys string) error {
+  return e.act("send keys to", func(we selenium.WebElement) error { return we.SendKeys(keys) })
+}
+
+// Fill replaces the contents of a text input with text
+func (e *Element) Fill(text string) error {
+  return e.act("fill", func(we selenium.WebElement) error {
+    if err := we.Clear(); err != nil {
+      return err
+    }
+    return we.SendKeys(text)
+  })
+}
+
+// Text returns the visible text of the element
+func (e *Element) Text() (text string, err error) {
+  err = e.do("retrieve text of", func(we selenium.WebElement) (err error) {
+    text, err = we.Text()
+    return err
+  })
+  return text, err
+}
+
+// Attribute returns the value of attribute name
+func (e *Element) Attribute(name string) (value string, err error) {
+  err = e.do("retrieve "+name+" of", func(we selenium.WebElement) (err error) {
+    value, err = we.GetAttribute(name)
+    return err
+  })
+  return value, err
+}
+
+var elementType = reflect.TypeOf((*Element)(nil))
+
+// Bind fills in the tagged *Element fields of page, which must be a pointer
+// to a struct, and hands it the session if it embeds PageBase
+func (s *Session) Bind(page interface{}) error {
+  v := reflect.ValueOf(page)
+  if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+    return fmt.Errorf("Cannot bind %T, a pointer to a struct is needed", page)
+  }
+  if b, ok := page.(sessionBinder); ok {
+    b.bindSession(s)
+  }
+
+  v = v.Elem()
+  t := v.Type()
+  for i := 0; i < t.NumField(); i++ {
+    f := t.Field(i)
+    spec, ok := f.Tag.Lookup("wd")
+    if !ok {
+      continue
+    }
+    if f.Type != elementType {
+      return fmt.Errorf("Field %s.%s has a wd tag but is not a *webdriver.Element", t.Name(), f.Name)
+    }
+    if f.PkgPath != "" {
+      return fmt.Errorf("Field %s.%s has a wd tag but is unexported, Bind cannot set it", t.Name(), f.Name)
+    }
+    loc, err := ParseLocator(spec)
+    if err != nil {
+      return fmt.Errorf("Field %s.%s: %w", t.Name(), f.Name, err)
+    }
+    v.Field(i).Set(reflect.ValueOf(s.NewElement(loc)))
+  }
+  return nil
+}
+
+// On binds page and waits for it to be ready, use it once an action has led to page
+func (s *Session) On(ctx context.Context, page Page) error {
+  if err := s.Bind(page); err != nil {
+    return err
+  }
+  if err := s.WaitUntil(ctx, page.Ready()); err != nil {
+    return fmt.Errorf("Not on %T: %w", page, err)
+  }
+  return nil
+}
+
+// Open loads page from its URL, then behaves like On
+func (s *Session) Open(ctx context.Context, page Page) error {
+  url := page.URL()
+  if url == "" {
+    return fmt.Errorf("%T has no URL to open", page)
+  }
+  if err := s.Navigate(url); err != nil {
+    return err
+  }
+  return s.On(ctx, page)
+}
+
+// Bind binds page to the default session
+func Bind(page interface{}) error {
+  return DefaultSession().Bind(page)
+}
+
+// On waits for page on the default session
+func On(ctx context.Context, page Page) error {
+  return DefaultSession().On(ctx, page)
+}
+
+// Open loads page on the default session
+func Open(ctx context.Context, page Page) error {
+  return DefaultSession().Open(ctx, page)
+}
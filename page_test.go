@@ -0,0 +1,98 @@
+package webdriver
+
+import (
+  "code.grantmurray.com/webdriver/fakewd"
+  "context"
+  "testing"
+)
+
+type testLoginPage struct {
+  PageBase
+  UserIdentifier *Element `wd:"name=UserIdentifier"`
+  ClearPassword  *Element `wd:"label=Password"`
+  LoginButton    *Element `wd:"css=button[name=\"LoginButton\"]"`
+  NotAnElement   string
+}
+
+func (p *testLoginPage) URL() string      { return loginURL }
+func (p *testLoginPage) Ready() Condition { return ElementAppears(CSS(`form[name="loginForm"]`)) }
+
+// LoginAs submits the form and returns the album page it leads to
+func (p *testLoginPage) LoginAs(user, password string) (*testAlbumPage, error) {
+  if err := p.UserIdentifier.Fill(user); err != nil {
+    return nil, err
+  }
+  if err := p.ClearPassword.Fill(password); err != nil {
+    return nil, err
+  }
+  if err := p.LoginButton.Click(); err != nil {
+    return nil, err
+  }
+  album := &testAlbumPage{}
+  return album, p.Session().On(context.Background(), album)
+}
+
+type testAlbumPage struct {
+  Logout *Element `wd:"link=Logout"`
+}
+
+func (p *testAlbumPage) URL() string      { return "https://plog.org:8004/#/album" }
+func (p *testAlbumPage) Ready() Condition { return URLIs(p.URL()) }
+
+func Test_Page_objects(t *testing.T) {
+  s, srv := newFakeSession(t)
+  loginPage(srv)
+  srv.Page(loginURL).Find("form").Add(
+    fakewd.E("label", "for", "pw").WithText("Password"),
+  )
+  srv.Page(loginURL).Find(`[name="ClearPassword"]`).Attrs["id"] = "pw"
+  srv.Page(loginURL).Find(`[name="LoginButton"]`).OnClick = func(b *fakewd.Browser) error {
+    b.Navigate("https://plog.org:8004/#/album")
+    return nil
+  }
+  srv.Page("https://plog.org:8004/#/album").Add(fakewd.E("a", "href", "").WithText("Logout"))
+
+  login := &testLoginPage{}
+  if err := s.Open(context.Background(), login); err != nil {
+    t.Fatalf("Open failed: %s", err)
+  }
+
+  // typed text survives a second Fill, which clears first
+  login.UserIdentifier.Fill("someone else")
+  album, err := login.LoginAs("Selenium-One", "sldkfjeowir9")
+  if err != nil {
+    t.Fatalf("LoginAs failed: %s", err)
+  }
+  if v := srv.Page(loginURL).Find(`[name="UserIdentifier"]`).Value(); v != "Selenium-One" {
+    t.Errorf("Expected UserIdentifier to hold Selenium-One, got %q", v)
+  }
+  if !album.Logout.Exists() {
+    t.Errorf("Expected the album page to have a Logout link")
+  }
+}
+
+func Test_Bind_errors(t *testing.T) {
+  s := NewSession(nil)
+
+  if err := s.Bind(testLoginPage{}); err == nil {
+    t.Errorf("Expected an error binding a non-pointer")
+  }
+  var bad struct {
+    Field string `wd:"name=x"`
+  }
+  if err := s.Bind(&bad); err == nil {
+    t.Errorf("Expected an error for a wd tag on a non-Element field")
+  }
+  var unknown struct {
+    Field *Element `wd:"smell=x"`
+  }
+  if err := s.Bind(&unknown); err == nil {
+    t.Errorf("Expected an error for an unknown locator strategy")
+  }
+  var unexported struct {
+    field *Element `wd:"name=x"`
+  }
+  if err := s.Bind(&unexported); err == nil || unexported.field != nil {
+    t.Errorf("Expected an error rather than a panic for an unexported field, got %v", err)
+  }
+}
@@ -2,10 +2,10 @@ package plog
 
 import (
 	"code.grantmurray.com/webdriver"
+	"context"
+	"errors"
 	"fmt"
-	"github.com/sourcegraph/go-selenium"
 	"testing"
-	"time"
 )
 
 type Login struct {
@@ -15,9 +15,8 @@ type Login struct {
 
 func ExpectOnLoginPage(t *testing.T) {
 
-	webdriver.WaitFor(5*time.Second, webdriver.ElementToAppear, "form[name=\"loginForm\"]")
-	if webdriver.WaitForTimedOut {
-		t.Fatalf("Expected to be on the login page, but timed out waiting for it")
+	if err := webdriver.WaitUntil(context.Background(), webdriver.ElementAppears(webdriver.CSS("form[name=\"loginForm\"]"))); err != nil {
+		t.Fatalf("Expected to be on the login page: %s", err)
 	}
 
 	// it may not be fully loaded yet ...
@@ -25,7 +24,7 @@ func ExpectOnLoginPage(t *testing.T) {
 }
 
 func GotoLogin(t *testing.T) {
-	err := webdriver.Drv.Get(`https://plog.org:8004/`)
+	err := webdriver.Navigate(`https://plog.org:8004/`)
 	if err != nil {
 		t.Fatalf("Goto login failed: %s", err)
 	}
@@ -33,58 +32,34 @@ func GotoLogin(t *testing.T) {
 
 func SubmitLogin(in Login, t *testing.T) {
 
-	// Get elements
-	elements, err := webdriver.FindNamedElements([]string{"UserIdentifier", "ClearPassword", "LoginButton"})
-	if err != nil {
-		t.Fatalf("FindNamedElements failed: %s", err)
+	page := &LoginPage{}
+	if err := webdriver.Bind(page); err != nil {
+		t.Fatalf("Bind failed: %s", err)
 	}
 
-	// Fill in
-	elements["UserIdentifier"].Clear()
-	elements["UserIdentifier"].SendKeys(in.UserIdentifier)
-	elements["ClearPassword"].Clear()
-	elements["ClearPassword"].SendKeys(in.ClearPassword)
-
-	// Submit
-	elements["LoginButton"].Click()
+	if err := page.Submit(in); err != nil {
+		t.Fatalf("SubmitLogin failed: %s", err)
+	}
 }
 
-func checkSessionToken(t *testing.T) bool {
-
-	js := `
-    var $injector = angular.injector(['ngCookies']);
-    return $injector.invoke(function($cookies) {
-      if ($cookies.SessionToken && ($cookies.SessionToken.length == 36)) {
-        return "present";
-      } else {
-        return "absent";
-      }
-    });
-    `
-
-	// check for SessionToken - it should be present if we logged in successfully, absent otherwise
-	result, err := webdriver.Drv.ExecuteScript(js, nil)
-	switch {
-	case err != nil:
-		t.Fatalf("Failed to ExecuteScript: %s", err)
-	case result == "present":
-		return true
-	case result == "absent":
-		return false
-	default:
-		t.Fatalf("checkSessionToken yielded unexpected result: %s", result)
-	}
-	return false
+// validSessionToken is the shape of the token the server hands out, a UUID
+func validSessionToken(c webdriver.Cookie) bool {
+	return len(c.Value) == 36
 }
 
 func ExpectSessionToken(t *testing.T) {
-	if !checkSessionToken(t) {
-		t.Fatalf("SessionToken expected to be present but was missing")
-	}
+	t.Helper()
+	webdriver.ExpectCookie(t, "SessionToken", validSessionToken)
 }
 
 func ExpectNoSessionToken(t *testing.T) {
-	if checkSessionToken(t) {
+	t.Helper()
+	c, err := webdriver.GetCookie("SessionToken")
+	switch {
+	case errors.Is(err, webdriver.ErrNoSuchCookie):
+	case err != nil:
+		t.Fatalf("Cannot check for the SessionToken cookie: %s", err)
+	case validSessionToken(c):
 		t.Fatalf("SessionToken expected to be absent but was found")
 	}
 }
@@ -94,7 +69,7 @@ const (
 )
 
 func Test_Login_Setup(t *testing.T) {
-	if err := webdriver.InitializeRemote(); err != nil {
+	if err := webdriver.InitializeRemote(remoteOptions...); err != nil {
 		t.Fatalf("Cannot connect to selenium server: %s", err)
 	}
 }
@@ -108,6 +83,8 @@ type loginCase struct {
 
 func Test_Login_table(t *testing.T) {
 
+	webdriver.CaptureOnFailure(t)
+
 	cases := []loginCase{
 		{"UserId", Login{"no such dude", "passwordpassword"}, "Authentication failed", "absent"},
 		{"UserId", Login{"wrong uid", userOne.ClearPassword}, "Authentication failed", "absent"},
@@ -121,15 +98,13 @@ func Test_Login_table(t *testing.T) {
 		t.Logf("Case %d: UserIdentifier (%s)=%s ClearPassword=%s", c, cur.idTyp, cur.lin.UserIdentifier, cur.lin.ClearPassword)
 
 		if len(cur.lin.ClearPassword) < 10 {
-			t.Fatalf("Case is not valid since ClearPassword (%s) is too short")
+			t.Fatalf("Case is not valid since ClearPassword (%s) is too short", cur.lin.ClearPassword)
 		}
 
 		GotoLogin(t)
 		ExpectOnLoginPage(t)
 		SubmitLogin(cur.lin, t)
 
-		webdriver.WaitFor(5*time.Second, webdriver.ElementToVanish, "div[class='selenium-flag']")
-
 		if cur.tokc == "absent" {
 			// Login failure was expected
 			msg, err := webdriver.FetchText("p[name='LoginMessage']")
@@ -152,24 +127,20 @@ func Logout(t *testing.T) {
 
 	ExpectSessionToken(t)
 
-	err := webdriver.Drv.Get(LogoutPageUrl)
-	if err != nil {
+	album := &AlbumPage{}
+	if err := webdriver.Open(context.Background(), album); err != nil {
 		t.Fatalf("Failed to load %s: %s\n", LogoutPageUrl, err)
 	}
-	webdriver.WaitFor(5*time.Second, webdriver.ElementToVanish, "div[class='selenium-flag']")
 
-	sel := "[name=\"Logout\"]"
-	LogoutLink, err := webdriver.Drv.FindElement(selenium.ByCSSSelector, sel)
-	if err != nil {
-		t.Fatalf("Failed to find element %s (%s)\n", sel, err)
+	if _, err := album.LogOut(); err != nil {
+		t.Fatalf("Logout failed: %s", err)
 	}
-
-	LogoutLink.Click() // BUG ng-click with href="" fails under selenium
-	ExpectOnLoginPage(t)
 	ExpectNoSessionToken(t)
 }
 
 func Test_Logout(t *testing.T) {
+	webdriver.CaptureOnFailure(t)
+
 	// happy path logout
 	t.Logf("Case: happy path logout")
 	Logout(t)
@@ -182,14 +153,13 @@ func Test_Logout(t *testing.T) {
 		page := fmt.Sprintf("https://plog.org:8004%s", pagesNeedingLogin[p])
 		t.Logf("Case: %s", pagesNeedingLogin[p])
 
-		err := webdriver.Drv.Get(page)
+		err := webdriver.Navigate(page)
 		if err != nil {
 			t.Fatalf("Failed to load %s: %s\n", page, err)
 		}
 
-		webdriver.WaitFor(5*time.Second, webdriver.UrlIsCurrent, "https://plog.org:8004/#/login")
-		if webdriver.WaitForTimedOut {
-			t.Fatalf("Failed to land at expected URL")
+		if err := webdriver.WaitUntil(context.Background(), webdriver.URLIs("https://plog.org:8004/#/login")); err != nil {
+			t.Fatalf("Failed to land at expected URL: %s", err)
 		}
 
 		msg, err := webdriver.FetchText("p[name='LoginMessage']")
@@ -201,13 +171,13 @@ func Test_Logout(t *testing.T) {
 			t.Errorf("Message was not blank: %s", msg)
 		}
 
-		result, err := webdriver.Drv.ExecuteScript("return localStorage['SessionToken']", nil)
+		token, found, err := webdriver.LocalStorage().Get("SessionToken")
 		if err != nil {
-			t.Fatalf("Failed to ExecuteScript: %s", err)
+			t.Fatalf("Failed to read localStorage: %s", err)
 		}
 
-		if result != nil {
-			t.Errorf("Found a SessionToken in localStorage: %s", result)
+		if found {
+			t.Errorf("Found a SessionToken in localStorage: %s", token)
 		}
 
 	}
@@ -218,41 +188,28 @@ func Test_Logout(t *testing.T) {
 	ExpectOnLoginPage(t)
 	SubmitLogin(Login{userOne.UserId, userOne.ClearPassword}, t)
 
-	webdriver.WaitFor(5*time.Second, webdriver.ElementToVanish, "div[class='selenium-flag']")
-
 	ExpectSessionToken(t)
 
 	// need to visit a page that needs login - the LogoutPageUrl page needs login
-	err := webdriver.Drv.Get(LogoutPageUrl)
+	err := webdriver.Navigate(LogoutPageUrl)
 	if err != nil {
 		t.Fatalf("Failed to load %s: %s\n", LogoutPageUrl, err)
 	}
 
-	webdriver.WaitFor(5*time.Second, webdriver.UrlIsCurrent, LogoutPageUrl)
-	if webdriver.WaitForTimedOut {
-		t.Fatalf("Failed to land at expected URL")
+	if err := webdriver.WaitUntil(context.Background(), webdriver.URLIs(LogoutPageUrl)); err != nil {
+		t.Fatalf("Failed to land at expected URL: %s", err)
 	}
 
 	// automatic login when SessionToken is present but not valid
 	t.Logf("Case: Automatic login with bad session token")
 
-	/* This angular method does not work, need to use the document.cookie method  :(
-	js := `
-	  var $injector = angular.injector(['ngCookies']);
-	  return $injector.invoke(function($cookies) {
-	    $cookies.SessionToken = '00000000-0000-0000-dead-beef00000000';
-	  });
-	  `
-	_, err = webdriver.Drv.ExecuteScript(js, nil)
-	*/
-
-	_, err = webdriver.Drv.ExecuteScript(`document.cookie = 'SessionToken=00000000-0000-0000-dead-beef00000000';`, nil)
+	err = webdriver.SetCookie(webdriver.Cookie{Name: "SessionToken", Value: "00000000-0000-0000-dead-beef00000000", Path: "/"})
 	if err != nil {
-		t.Fatalf("Failed to ExecuteScript: %s", err)
+		t.Fatalf("Failed to set the SessionToken cookie: %s", err)
 	}
 
 	// need to visit a page that needs login - the LogoutPageUrl page needs login
-	err = webdriver.Drv.Get(LogoutPageUrl)
+	err = webdriver.Navigate(LogoutPageUrl)
 	if err != nil {
 		t.Fatalf("Failed to load %s: %s\n", LogoutPageUrl, err)
 	}
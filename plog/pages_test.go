@@ -0,0 +1,137 @@
+package plog
+
+import (
+	"code.grantmurray.com/webdriver"
+	"context"
+)
+
+// seleniumFlag is shown by the plog UI while it is busy talking to the server
+var seleniumFlag = webdriver.CSS("div[class='selenium-flag']")
+
+// remoteOptions are what every Setup connects with: the session waits for
+// AngularJS before finding elements and for seleniumFlag after acting on them
+var remoteOptions = []webdriver.Option{
+	webdriver.WithAngularSync(),
+	webdriver.WithBusyMarker(webdriver.BusyMarker{Locator: seleniumFlag}),
+}
+
+// LoginPage is the login form, pages needing a login redirect here
+type LoginPage struct {
+	webdriver.PageBase
+	UserIdentifier *webdriver.Element `wd:"name=UserIdentifier"`
+	ClearPassword  *webdriver.Element `wd:"name=ClearPassword"`
+	LoginButton    *webdriver.Element `wd:"name=LoginButton"`
+	LoginMessage   *webdriver.Element `wd:"name=LoginMessage"`
+}
+
+func (p *LoginPage) URL() string { return "https://plog.org:8004/" }
+
+func (p *LoginPage) Ready() webdriver.Condition {
+	return webdriver.ElementAppears(webdriver.CSS("form[name=\"loginForm\"]"))
+}
+
+// Submit fills in the form and presses the login button. The click waits for the busy marker to clear, not for the page it leads to.
+func (p *LoginPage) Submit(in Login) error {
+	if err := p.UserIdentifier.Fill(in.UserIdentifier); err != nil {
+		return err
+	}
+	if err := p.ClearPassword.Fill(in.ClearPassword); err != nil {
+		return err
+	}
+	return p.LoginButton.Click()
+}
+
+// AlbumPage lists the albums, it needs a login
+type AlbumPage struct {
+	webdriver.PageBase
+	Logout *webdriver.Element `wd:"name=Logout"`
+}
+
+func (p *AlbumPage) URL() string { return LogoutPageUrl }
+
+func (p *AlbumPage) Ready() webdriver.Condition {
+	return webdriver.And(webdriver.URLIs(LogoutPageUrl), webdriver.ElementVanishes(seleniumFlag))
+}
+
+// LogOut clicks the Logout link which leads back to the login page
+func (p *AlbumPage) LogOut() (*LoginPage, error) {
+	if err := p.Logout.Click(); err != nil { // BUG ng-click with href="" fails under selenium
+		return nil, err
+	}
+	login := &LoginPage{}
+	return login, p.Session().On(context.Background(), login)
+}
+
+// RegisterPage is the new user registration form
+type RegisterPage struct {
+	webdriver.PageBase
+	RegisterButton *webdriver.Element `wd:"name=RegisterButton"`
+}
+
+// registerForm is the form holding the register button, it has no name of its own
+var registerForm = webdriver.XPath("//form[.//*[@name='RegisterButton']]")
+
+func (p *RegisterPage) URL() string { return "https://plog.org:8004/#/register" }
+
+func (p *RegisterPage) Ready() webdriver.Condition {
+	return webdriver.And(webdriver.ElementAppears(webdriver.Name("RegisterButton")), webdriver.ElementVanishes(seleniumFlag))
+}
+
+// Submit fills in the form and presses the register button. The click waits for the busy marker to clear, not for the page it leads to.
+func (p *RegisterPage) Submit(regU RegisterUser) error {
+	if err := p.Session().FillForm(registerForm, regU); err != nil {
+		return err
+	}
+	return p.RegisterButton.Click()
+}
+
+// ProfilePage shows and edits the logged in user's profile
+type ProfilePage struct {
+	webdriver.PageBase
+	SaveProfileButton *webdriver.Element `wd:"name=SaveProfileButton"`
+	Message           *webdriver.Element `wd:"name=Message"`
+}
+
+var profileForm = webdriver.Name("editProfileForm")
+
+func (p *ProfilePage) URL() string { return "https://plog.org:8004/#/profile" }
+
+func (p *ProfilePage) Ready() webdriver.Condition {
+	return webdriver.And(webdriver.ElementAppears(profileForm), webdriver.ElementVanishes(seleniumFlag))
+}
+
+// Submit replaces the fields that are set in profU and presses the save button. The click waits for the busy marker to clear, not for the page it leads to.
+func (p *ProfilePage) Submit(profU UserProfile) error {
+	if err := p.Session().FillForm(profileForm, profU, webdriver.SkipZero()); err != nil {
+		return err
+	}
+	return p.SaveProfileButton.Click()
+}
+
+// Profile reads what the form currently shows
+func (p *ProfilePage) Profile() (profU UserProfile, err error) {
+	err = p.Session().ReadForm(profileForm, &profU)
+	return profU, err
+}
+
+// PasswordPage requests a password reset email
+type PasswordPage struct {
+	webdriver.PageBase
+	EmailAddr           *webdriver.Element `wd:"name=EmailAddr"`
+	ResetPasswordButton *webdriver.Element `wd:"name=ResetPasswordButton"`
+	Message             *webdriver.Element `wd:"name=Message"`
+}
+
+func (p *PasswordPage) URL() string { return "https://plog.org:8004/#/password" }
+
+func (p *PasswordPage) Ready() webdriver.Condition {
+	return webdriver.And(webdriver.ElementAppears(webdriver.Name("ResetPasswordButton")), webdriver.ElementVanishes(seleniumFlag))
+}
+
+// RequestReset asks for a reset token to be sent to emailAddr. The click waits for the busy marker to clear, not for the page it leads to.
+func (p *PasswordPage) RequestReset(emailAddr string) error {
+	if err := p.EmailAddr.Fill(emailAddr); err != nil {
+		return err
+	}
+	return p.ResetPasswordButton.Click()
+}
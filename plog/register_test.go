@@ -1,11 +1,11 @@
 package plog
 
 import (
+	"context"
 	"fmt"
 	//"github.com/sourcegraph/go-selenium"
 	"code.grantmurray.com/webdriver"
 	"testing"
-	"time"
 )
 
 // RegisterUser is used to register new users in tests
@@ -21,12 +21,11 @@ type RegisterUser struct {
 // SubmitRegistration fills in the form and presses the register button. It does not wait after the click.
 func SubmitRegistration(regU RegisterUser, t *testing.T) {
 
-	// Get and wait
-	err := webdriver.Drv.Get(`https://plog.org:8004/#/register`)
-	if err != nil {
+	// Navigate waits for the route to load
+	page := &RegisterPage{}
+	if err := webdriver.Open(context.Background(), page); err != nil {
 		t.Fatalf("Failed to load page: %s", err)
 	}
-	webdriver.WaitFor(5*time.Second, webdriver.ElementToVanish, "div[class='selenium-flag']")
 
 	// Check page title
 	pageTitle := "ZM Plog"
@@ -38,32 +37,17 @@ func SubmitRegistration(regU RegisterUser, t *testing.T) {
 		t.Fatalf("Failed to get page title: %s", err)
 	}
 
-	// Get elements
-	elements, err := webdriver.FindNamedElements([]string{"UserId", "FirstName", "LastName", "EmailAddr",
-		"TzName", "ClearPassword", "ConfirmPassword", "RegisterButton"})
-	if err != nil {
-		t.Fatalf("FindNamedElements failed: %s", err)
+	// Fill and submit
+	if err := page.Submit(regU); err != nil {
+		t.Fatalf("SubmitRegistration failed: %s", err)
 	}
 
-	// Fill
-	elements["UserId"].SendKeys(regU.UserId)
-	elements["FirstName"].SendKeys(regU.FirstName)
-	elements["LastName"].SendKeys(regU.LastName)
-	elements["EmailAddr"].SendKeys(regU.EmailAddr)
-	elements["ClearPassword"].SendKeys(regU.ClearPassword)
-	elements["ConfirmPassword"].SendKeys(regU.ConfirmPassword)
-
-	// Submit
-	elements["RegisterButton"].Click()
-
 	// NOT waiting here
 }
 
 // ExpectRegistrationSuccess gets call immediately after SubmitRegistration and verifies a successful registration
 func ExpectRegistrationSuccess(email string, t *testing.T) {
 
-	webdriver.WaitFor(5*time.Second, webdriver.ElementToVanish, "div[class='selenium-flag']")
-
 	msg, err := webdriver.FetchText("p[name='Message']")
 	if err != nil {
 		t.Fatalf("Failed to fetch the main message: %s", err)
@@ -88,18 +72,16 @@ type UserProfile struct {
 	ConfirmPassword string
 }
 
-var profExpected map[string]string = map[string]string{
-	"UserId":          "selenium-one",
-	"FirstName":       "George",
-	"LastName":        "Katsiopolous",
-	"EmailAddr":       "georgek@mailbot.net",
-	"ClearPassword":   "",
-	"ConfirmPassword": ""}
+var profExpected UserProfile = UserProfile{
+	UserId:    "selenium-one",
+	FirstName: "George",
+	LastName:  "Katsiopolous",
+	EmailAddr: "georgek@mailbot.net"}
 
 // GotoProfile attempts to load the url, but we could end up on the login page if we are not logged in
 func GotoProfile(t *testing.T) {
-	// Get and wait
-	err := webdriver.Drv.Get(`https://plog.org:8004/#/profile`)
+	// Navigate waits for the route to load
+	err := webdriver.Navigate(`https://plog.org:8004/#/profile`)
 	if err != nil {
 		t.Fatalf("Failed to load page: %s", err)
 	}
@@ -107,80 +89,39 @@ func GotoProfile(t *testing.T) {
 
 func ExpectOnProfilePage(t *testing.T) {
 
-	webdriver.WaitFor(5*time.Second, webdriver.ElementToVanish, "div[class='selenium-flag']")
-
-	elist := []string{"editProfileForm", "Message", "UserId", "FirstName", "LastName", "EmailAddr",
-		"ClearPassword", "ConfirmPassword", "SaveProfileButton"}
+	page := &ProfilePage{}
+	if err := webdriver.On(context.Background(), page); err != nil {
+		t.Fatalf("Not on the profile page: %s", err)
+	}
 
-	// Get elements
-	elements, err := webdriver.FindNamedElements(elist)
+	got, err := page.Profile()
 	if err != nil {
-		t.Fatalf("FindNamedElements failed: %s", err)
+		t.Fatalf("Failed to read the profile form: %s", err)
 	}
 
-	var val string
-
-	for k, v := range profExpected {
-		val, err = elements[k].GetAttribute("value")
-		if err != nil {
-			t.Fatalf("Failed to get vlaue in %s: %s", k, err)
-		}
-
-		if val != v {
-			t.Fatalf("Expected %s value \"%s\", got \"%s\"", k, v, val)
-		}
+	if got != profExpected {
+		t.Fatalf("Expected profile %+v, got %+v", profExpected, got)
 	}
-
 }
 
 // SubmitProfileChange fills in the form and presses the save button. It does not wait after the click.
 func SubmitProfileChange(profU UserProfile, t *testing.T) {
 
-	webdriver.WaitFor(5*time.Second, webdriver.ElementToVanish, "div[class='selenium-flag']")
-
-	// Get elements
-	elements, err := webdriver.FindNamedElements([]string{"UserId", "FirstName", "LastName", "EmailAddr",
-		"TzName", "ClearPassword", "ConfirmPassword", "SaveProfileButton"})
-	if err != nil {
-		t.Fatalf("FindNamedElements failed: %s", err)
+	page := &ProfilePage{}
+	if err := webdriver.On(context.Background(), page); err != nil {
+		t.Fatalf("Not on the profile page: %s", err)
 	}
 
-	// Fill
-	if profU.UserId != "" {
-		elements["UserId"].Clear()
-		elements["UserId"].SendKeys(profU.UserId)
-	}
-	if profU.FirstName != "" {
-		elements["FirstName"].Clear()
-		elements["FirstName"].SendKeys(profU.FirstName)
-	}
-	if profU.LastName != "" {
-		elements["LastName"].Clear()
-		elements["LastName"].SendKeys(profU.LastName)
-	}
-	if profU.EmailAddr != "" {
-		elements["EmailAddr"].Clear()
-		elements["EmailAddr"].SendKeys(profU.EmailAddr)
-	}
-	if profU.ClearPassword != "" {
-		elements["ClearPassword"].Clear()
-		elements["ClearPassword"].SendKeys(profU.ClearPassword)
-	}
-	if profU.ConfirmPassword != "" {
-		elements["ConfirmPassword"].Clear()
-		elements["ConfirmPassword"].SendKeys(profU.ConfirmPassword)
+	// Fill and submit
+	if err := page.Submit(profU); err != nil {
+		t.Fatalf("SubmitProfileChange failed: %s", err)
 	}
 
-	// Submit
-	elements["SaveProfileButton"].Click()
-
 	// NOT waiting here
 }
 
 func ExpectProfileChangeSuccess(t *testing.T) {
 
-	webdriver.WaitFor(5*time.Second, webdriver.ElementToVanish, "div[class='selenium-flag']")
-
 	// Get elements
 	elements, err := webdriver.FindNamedElements([]string{"Message", "UserId", "FirstName", "LastName", "EmailAddr",
 		"TzName", "ClearPassword", "ConfirmPassword", "SaveProfileButton"})
@@ -200,12 +141,14 @@ func ExpectProfileChangeSuccess(t *testing.T) {
 /******** Tests Start Here *********/
 
 func Test_Setup(t *testing.T) {
-	if err := webdriver.InitializeRemote(); err != nil {
+	if err := webdriver.InitializeRemote(remoteOptions...); err != nil {
 		t.Fatalf("Cannot connect to selenium server: %s", err)
 	}
 }
 
 func Test_Register_Success(t *testing.T) {
+	webdriver.CaptureOnFailure(t)
+
 	SubmitRegistration(userOne, t)
 	ExpectRegistrationSuccess(userOne.EmailAddr, t)
 	VerifyEmailAddressFor(`georgek@mailbot.net`, t)
@@ -217,8 +160,9 @@ func Test_Register_Success(t *testing.T) {
 
 func Test_Register_AlreadyRegistered(t *testing.T) {
 
+	webdriver.CaptureOnFailure(t)
+
 	SubmitRegistration(userOne, t)
-	webdriver.WaitFor(5*time.Second, webdriver.ElementToVanish, "div[class='selenium-flag']")
 
 	// Case 1: non-unique email
 	const emailErr = "Already associated with a user"
@@ -239,7 +183,6 @@ func Test_Register_AlreadyRegistered(t *testing.T) {
 
 	SubmitRegistration(userOne, t)
 	userOne.EmailAddr = origEmailAddr
-	webdriver.WaitFor(5*time.Second, webdriver.ElementToVanish, "div[class='selenium-flag']")
 
 	msg, err = webdriver.FetchText("span[name='UserIdErrorMsg']")
 	if err != nil {
@@ -253,6 +196,8 @@ func Test_Register_AlreadyRegistered(t *testing.T) {
 
 func Test_Profile_NeedsLogin(t *testing.T) {
 
+	webdriver.CaptureOnFailure(t)
+
 	GotoProfile(t)
 	ExpectOnLoginPage(t)
 	SubmitLogin(Login{userOne.UserId, userOne.ClearPassword}, t)
@@ -262,53 +207,61 @@ func Test_Profile_NeedsLogin(t *testing.T) {
 
 func Test_Profile_Success(t *testing.T) {
 
+	webdriver.CaptureOnFailure(t)
+
 	var profU UserProfile
 	profU.UserId = `Selenium-Changed`
 
 	GotoProfile(t)
 	ExpectOnProfilePage(t)
 	SubmitProfileChange(profU, t)
-	profExpected["UserId"] = "Selenium-Changed"
+	profExpected.UserId = "Selenium-Changed"
 	ExpectProfileChangeSuccess(t)
 	ExpectOnProfilePage(t)
 }
 
 func Test_Profile_FirstName_change(t *testing.T) {
 
+	webdriver.CaptureOnFailure(t)
+
 	var profU UserProfile
 	profU.FirstName = `NewFirstName`
-	profExpected["UserId"] = "selenium-changed"
+	profExpected.UserId = "selenium-changed"
 
 	GotoProfile(t)
 	ExpectOnProfilePage(t)
 	SubmitProfileChange(profU, t)
-	profExpected["FirstName"] = profU.FirstName
+	profExpected.FirstName = profU.FirstName
 	ExpectProfileChangeSuccess(t)
 	ExpectOnProfilePage(t)
 }
 
 func Test_Profile_LastName_change(t *testing.T) {
 
+	webdriver.CaptureOnFailure(t)
+
 	var profU UserProfile
 	profU.LastName = `NewLastName`
 
 	GotoProfile(t)
 	ExpectOnProfilePage(t)
 	SubmitProfileChange(profU, t)
-	profExpected["LastName"] = profU.LastName
+	profExpected.LastName = profU.LastName
 	ExpectProfileChangeSuccess(t)
 	ExpectOnProfilePage(t)
 }
 
 func Test_Profile_EmailAddr_change(t *testing.T) {
 
+	webdriver.CaptureOnFailure(t)
+
 	var profU UserProfile
 	profU.EmailAddr = `bigdeal@little-planet.com`
 
 	GotoProfile(t)
 	ExpectOnProfilePage(t)
 	SubmitProfileChange(profU, t)
-	profExpected["EmailAddr"] = profU.EmailAddr
+	profExpected.EmailAddr = profU.EmailAddr
 	ExpectProfileChangeSuccess(t)
 	ExpectOnProfilePage(t)
 	VerifyEmailAddressFor(profU.EmailAddr, t)
@@ -316,6 +269,8 @@ func Test_Profile_EmailAddr_change(t *testing.T) {
 
 func Test_Profile_password_change(t *testing.T) {
 
+	webdriver.CaptureOnFailure(t)
+
 	var profU UserProfile
 	profU.ClearPassword = `New-Password-1234`
 	profU.ConfirmPassword = profU.ClearPassword
@@ -330,13 +285,15 @@ func Test_Profile_password_change(t *testing.T) {
 	Logout(t)
 	GotoProfile(t)
 	ExpectOnLoginPage(t)
-	SubmitLogin(Login{profExpected["UserId"], profU.ClearPassword}, t)
+	SubmitLogin(Login{profExpected.UserId, profU.ClearPassword}, t)
 	ExpectOnProfilePage(t)
 
 }
 
 func Test_Profile_change_all_back(t *testing.T) {
 
+	webdriver.CaptureOnFailure(t)
+
 	var profU UserProfile = UserProfile{userOne.UserId, userOne.FirstName,
 		userOne.LastName, userOne.EmailAddr, userOne.ClearPassword, userOne.ConfirmPassword}
 
@@ -344,13 +301,11 @@ func Test_Profile_change_all_back(t *testing.T) {
 	ExpectOnProfilePage(t)
 	SubmitProfileChange(profU, t)
 
-	profExpected = map[string]string{
-		"UserId":          "Selenium-One",
-		"FirstName":       "George",
-		"LastName":        "Katsiopolous",
-		"EmailAddr":       "GeorgeK@mailbot.NET",
-		"ClearPassword":   "",
-		"ConfirmPassword": ""}
+	profExpected = UserProfile{
+		UserId:    "Selenium-One",
+		FirstName: "George",
+		LastName:  "Katsiopolous",
+		EmailAddr: "GeorgeK@mailbot.NET"}
 	ExpectProfileChangeSuccess(t)
 	ExpectOnProfilePage(t)
 	VerifyEmailAddressFor(`georgek@mailbot.net`, t)
@@ -360,8 +315,8 @@ func Test_Profile_change_all_back(t *testing.T) {
 	GotoProfile(t)
 	ExpectOnLoginPage(t)
 	SubmitLogin(Login{userOne.UserId, userOne.ClearPassword}, t)
-	profExpected["UserId"] = `selenium-one`
-	profExpected["EmailAddr"] = `georgek@mailbot.net`
+	profExpected.UserId = `selenium-one`
+	profExpected.EmailAddr = `georgek@mailbot.net`
 	ExpectOnProfilePage(t)
 
 }
@@ -2,43 +2,31 @@ package plog
 
 import (
 	"code.grantmurray.com/webdriver"
+	"context"
 	"fmt"
 	"strings"
 	"testing"
-	"time"
 )
 
 /******** Tests Start Here *********/
 
 func Test_ResetPW_Setup(t *testing.T) {
-	if err := webdriver.InitializeRemote(); err != nil {
+	if err := webdriver.InitializeRemote(remoteOptions...); err != nil {
 		t.Fatalf("Cannot connect to selenium server: %s", err)
 	}
 }
 
 func RequestPasswordResetFor(inEmailAddr string, t *testing.T) {
-	url := "https://plog.org:8004/#/password"
-	err := webdriver.Drv.Get(url)
-	if err != nil {
-		t.Fatalf("Failed to load %s: %s\n", url, err)
+	page := &PasswordPage{}
+	if err := webdriver.Open(context.Background(), page); err != nil {
+		t.Fatalf("Failed to load %s: %s\n", page.URL(), err)
 	}
-	webdriver.WaitFor(5*time.Second, webdriver.ElementToVanish, "div[class='selenium-flag']")
 
-	// Get elements
-	elements, err := webdriver.FindNamedElements([]string{"Message", "EmailAddr", "ResetPasswordButton"})
-	if err != nil {
-		t.Fatalf("FindNamedElements failed: %s", err)
+	// Fill and submit
+	if err := page.RequestReset(inEmailAddr); err != nil {
+		t.Fatalf("RequestReset failed: %s", err)
 	}
 
-	// Fill
-	elements["EmailAddr"].Clear()
-	elements["EmailAddr"].SendKeys(inEmailAddr)
-
-	// Submit
-	elements["ResetPasswordButton"].Click()
-
-	webdriver.WaitFor(5*time.Second, webdriver.ElementToVanish, "div[class='selenium-flag']")
-
 	// Verify expected results
 	actualMsg, err := webdriver.FetchText("p[name='Message']")
 	if err != nil {
@@ -53,6 +41,8 @@ func RequestPasswordResetFor(inEmailAddr string, t *testing.T) {
 }
 
 func Test_ResetPW_request_success(t *testing.T) {
+	webdriver.CaptureOnFailure(t)
+
 	RequestPasswordResetFor(userOne.EmailAddr, t)
 
 	email := SlurpEmail(userOne.EmailAddr, t)
@@ -20,7 +20,7 @@ sudo rm -f /tmp/sessdb.*
 sudo rm -f /tmp/session.test*
 
 cd $GOPATH/src/code.grantmurray.com/webdriver/plog
-go test register_test.go verifyemail_test.go login_test.go resetpw_test.go -v 2>&1 | grep -v '^.selenium] '
+go test pages_test.go register_test.go verifyemail_test.go login_test.go resetpw_test.go -v 2>&1 | grep -v '^.selenium] '
 
 PSQL="psql --username=postgres --dbname=sessdb"
 $PSQL -c 'select * from session.user' --expanded > /tmp/webdriver.db.user
@@ -8,7 +8,6 @@ import (
 	"os"
 	"strings"
 	"testing"
-	"time"
 )
 
 var (
@@ -17,7 +16,7 @@ var (
 )
 
 func Test_Verify_Setup(t *testing.T) {
-	if err := webdriver.InitializeRemote(); err != nil {
+	if err := webdriver.InitializeRemote(remoteOptions...); err != nil {
 		t.Fatalf("Cannot connect to selenium server: %s", err)
 	}
 }
@@ -30,11 +29,10 @@ type vCase struct {
 
 func doVerifyCase(t *testing.T, cur vCase) {
 	url := fmt.Sprintf("https://plog.org:8004/#/verify/%s/token/%s", cur.email, cur.tok)
-	err := webdriver.Drv.Get(url)
+	err := webdriver.Navigate(url)
 	if err != nil {
 		t.Fatalf("Failed to load %s: %s\n", url, err)
 	}
-	webdriver.WaitFor(5*time.Second, webdriver.ElementToVanish, "div[class='selenium-flag']")
 
 	actualMsg, err := webdriver.FetchText("p[name='Message']")
 	if err != nil {
@@ -94,6 +92,8 @@ func VerifyEmailAddressFor(expectedAddr string, t *testing.T) {
 
 func Test_VerifyEmail_BadAddress(t *testing.T) {
 
+	webdriver.CaptureOnFailure(t)
+
 	cases := []vCase{
 		{"WTF-Email", "6ba7b814-9dad-11d1-80b4-00c04fd430c8", "Failed! Server says: Verification failed; Not a valid email address"},
 		{"matches@pattern.io", "toktok", "Failed! Server says: Verification failed; Not a valid token"},
@@ -109,6 +109,8 @@ func Test_VerifyEmail_BadAddress(t *testing.T) {
 
 func Test_Verify_Success(t *testing.T) {
 
+	webdriver.CaptureOnFailure(t)
+
 	VerifyEmailAddressFor(`jplain@mailbot.net`, t)
 }
 
@@ -0,0 +1,524 @@
+package webdriver
+
+import (
+  "bufio"
+  "bytes"
+  "compress/gzip"
+  "crypto/ecdsa"
+  "crypto/elliptic"
+  "crypto/rand"
+  "crypto/tls"
+  "crypto/x509"
+  "crypto/x509/pkix"
+  "encoding/pem"
+  "errors"
+  "fmt"
+  "io/ioutil"
+  "math/big"
+  "net"
+  "net/http"
+  "net/http/httptrace"
+  "net/url"
+  "os"
+  "path/filepath"
+  "strings"
+  "sync"
+  "testing"
+  "time"
+)
+
+// Proxy is an HTTP and HTTPS proxy that records everything the browser sends
+// through it. HTTPS is intercepted: the proxy answers CONNECT itself with a
+// certificate for the host signed by its own CA, which the browser has to be
+// told to accept (WithRecordingProxy does that). Upstream certificates are
+// not checked, this is a testing tool.
+//
+// Chrome bypasses proxies for localhost, so pages served from localhost are
+// not seen
+type Proxy struct {
+  Addr string            // host:port to point the browser at
+  CA   *x509.Certificate // signs the certificates used for HTTPS
+
+  ln        net.Listener
+  srv       *http.Server
+  transport *http.Transport
+  caKey     *ecdsa.PrivateKey
+  leafKey   *ecdsa.PrivateKey
+
+  routes routes // stubs, see Route
+
+  mu      sync.Mutex
+  entries []HAREntry
+  certs   map[string]*tls.Certificate
+  network NetworkConditions // see SetNetwork
+}
+
+// StartProxy starts a recording proxy listening on addr, "" picks a free port on the loopback interface
+func StartProxy(addr string) (*Proxy, error) {
+  if addr == "" {
+    addr = "127.0.0.1:0"
+  }
+  p := &Proxy{
+    certs: map[string]*tls.Certificate{},
+    transport: &http.Transport{
+      TLSClientConfig:     &tls.Config{InsecureSkipVerify: true},
+      MaxIdleConnsPerHost: 8,
+      IdleConnTimeout:     30 * time.Second,
+    },
+  }
+  if err := p.makeCA(); err != nil {
+    return nil, fmt.Errorf("Cannot create the proxy CA: %w", err)
+  }
+
+  ln, err := net.Listen("tcp", addr)
+  if err != nil {
+    return nil, err
+  }
+  p.ln, p.Addr = ln, ln.Addr().String()
+  p.srv = &http.Server{Handler: p}
+  go p.srv.Serve(ln)
+  return p, nil
+}
+
+// UseUpstream sends the proxy's own traffic through another proxy at hostport
+func (p *Proxy) UseUpstream(hostport string) {
+  p.transport.Proxy = http.ProxyURL(&url.URL{Scheme: "http", Host: hostport})
+}
+
+// Close stops the proxy, recorded entries stay available
+func (p *Proxy) Close() error {
+  p.transport.CloseIdleConnections()
+  return p.srv.Close()
+}
+
+// Entries returns a copy of what has been recorded since the start or the last Reset
+func (p *Proxy) Entries() []HAREntry {
+  p.mu.Lock()
+  defer p.mu.Unlock()
+  return append([]HAREntry(nil), p.entries...)
+}
+
+// Reset forgets the recorded entries
+func (p *Proxy) Reset() {
+  p.mu.Lock()
+  defer p.mu.Unlock()
+  p.entries = nil
+}
+
+// HAR returns the recorded entries as a HAR document
+func (p *Proxy) HAR() *HAR {
+  return NewHAR(p.Entries())
+}
+
+// WriteCA saves the CA certificate as PEM, for browsers that are to trust it
+// rather than accept any certificate
+func (p *Proxy) WriteCA(filename string) error {
+  return ioutil.WriteFile(filename, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: p.CA.Raw}), 0644)
+}
+
+func (p *Proxy) record(e HAREntry) {
+  p.mu.Lock()
+  defer p.mu.Unlock()
+  p.entries = append(p.entries, e)
+}
+
+// count and since let a caller pick out what was recorded after a point
+func (p *Proxy) count() int {
+  p.mu.Lock()
+  defer p.mu.Unlock()
+  return len(p.entries)
+}
+
+func (p *Proxy) since(n int) []HAREntry {
+  p.mu.Lock()
+  defer p.mu.Unlock()
+  if n > len(p.entries) {
+    n = 0 // there was a Reset in between
+  }
+  return append([]HAREntry(nil), p.entries[n:]...)
+}
+
+func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+  switch {
+  case r.Method == http.MethodConnect:
+    p.intercept(w, r)
+  case r.URL.IsAbs():
+    resp, body, err := p.exchange(r)
+    if errors.Is(err, errAborted) {
+      panic(http.ErrAbortHandler)
+    }
+    if err != nil {
+      http.Error(w, err.Error(), http.StatusBadGateway)
+      return
+    }
+    for name, values := range resp.Header {
+      if !hopHeader(name) {
+        w.Header()[name] = values
+      }
+    }
+    w.Header().Set("Content-Length", fmt.Sprint(len(body)))
+    w.WriteHeader(resp.StatusCode)
+    w.Write(body)
+  default:
+    http.Error(w, "This is a proxy, not a web server", http.StatusBadRequest)
+  }
+}
+
+// intercept answers a CONNECT by posing as the host and handles the
+// requests that come through the tunnel itself
+func (p *Proxy) intercept(w http.ResponseWriter, r *http.Request) {
+  hj, ok := w.(http.Hijacker)
+  if !ok {
+    http.Error(w, "Cannot take over the connection", http.StatusInternalServerError)
+    return
+  }
+  conn, _, err := hj.Hijack()
+  if err != nil {
+    return
+  }
+  defer conn.Close()
+  if _, err = conn.Write([]byte("HTTP/1.1 200 Connection established\r\n\r\n")); err != nil {
+    return
+  }
+
+  host := r.Host
+  tlsConn := tls.Server(conn, &tls.Config{
+    NextProtos: []string{"http/1.1"},
+    GetCertificate: func(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+      name := hello.ServerName
+      if name == "" {
+        name, _, _ = net.SplitHostPort(host)
+      }
+      return p.cert(name)
+    },
+  })
+  if err = tlsConn.Handshake(); err != nil {
+    return
+  }
+
+  br := bufio.NewReader(tlsConn)
+  for {
+    req, err := http.ReadRequest(br)
+    if err != nil {
+      return
+    }
+    req.URL.Scheme, req.URL.Host = "https", strings.TrimSuffix(host, ":443")
+    req.RemoteAddr = conn.RemoteAddr().String()
+
+    resp, body, err := p.exchange(req)
+    if errors.Is(err, errAborted) {
+      return
+    }
+    if err != nil {
+      resp = &http.Response{StatusCode: http.StatusBadGateway, Header: http.Header{}}
+      body = []byte(err.Error())
+    }
+    for name := range resp.Header {
+      if hopHeader(name) {
+        resp.Header.Del(name)
+      }
+    }
+    out := &http.Response{
+      StatusCode:    resp.StatusCode,
+      ProtoMajor:    1,
+      ProtoMinor:    1,
+      Header:        resp.Header,
+      Body:          ioutil.NopCloser(bytes.NewReader(body)),
+      ContentLength: int64(len(body)),
+      Close:         req.Close,
+    }
+    if err = out.Write(tlsConn); err != nil || req.Close {
+      return
+    }
+  }
+}
+
+// hopHeader reports headers that belong to a single connection and are not passed on
+func hopHeader(name string) bool {
+  switch http.CanonicalHeaderKey(name) {
+  case "Connection", "Proxy-Connection", "Keep-Alive", "Proxy-Authenticate", "Proxy-Authorization",
+    "Te", "Trailer", "Transfer-Encoding", "Upgrade":
+    return true
+  }
+  return false
+}
+
+// exchange sends r on to its server, or answers it from a matching route,
+// and records the round trip
+func (p *Proxy) exchange(r *http.Request) (*http.Response, []byte, error) {
+  started := time.Now()
+  reqBody, err := ioutil.ReadAll(r.Body)
+  if err != nil {
+    return nil, nil, err
+  }
+  r.Body.Close()
+
+  entry := HAREntry{StartedDateTime: started, Request: harRequest(r, reqBody)}
+
+  network := p.Network()
+  if err := network.sending(r.Context(), len(reqBody)); err != nil {
+    entry.Response = noResponse()
+    entry.Comment = err.Error()
+    entry.Time = millis(time.Since(started))
+    entry.Timings = HARTimings{Blocked: entry.Time, DNS: -1, Connect: -1, SSL: -1}
+    p.record(entry)
+    return nil, nil, err
+  }
+
+  if resp, body, handled, err := p.stubbed(r); handled {
+    if err == nil {
+      err = network.receiving(r.Context(), len(body))
+    }
+    finished := time.Now()
+    entry.Time = millis(finished.Sub(started))
+    entry.Timings = HARTimings{Blocked: -1, DNS: -1, Connect: -1, SSL: -1, Wait: entry.Time}
+    entry.Comment = "stubbed"
+    if err != nil {
+      entry.Response = noResponse()
+      entry.Comment = err.Error()
+    } else {
+      entry.Response = harResponse(resp, body)
+    }
+    p.record(entry)
+    return resp, body, err
+  }
+
+  out := r.Clone(r.Context())
+  out.RequestURI = ""
+  out.Body = ioutil.NopCloser(bytes.NewReader(reqBody))
+  out.ContentLength = int64(len(reqBody))
+  for name := range out.Header {
+    if hopHeader(name) {
+      out.Header.Del(name)
+    }
+  }
+
+  var tr traceTimes
+  out = out.WithContext(httptrace.WithClientTrace(out.Context(), tr.trace()))
+
+  resp, err := p.transport.RoundTrip(out)
+  if err != nil {
+    entry.Response = noResponse()
+    entry.Comment = err.Error()
+    entry.Timings, entry.Time = tr.timings(started, time.Now())
+    p.record(entry)
+    return nil, nil, err
+  }
+  body, err := ioutil.ReadAll(resp.Body)
+  resp.Body.Close()
+  if err == nil {
+    err = network.receiving(r.Context(), len(body))
+  }
+  finished := time.Now()
+  if err != nil {
+    entry.Response = noResponse()
+    entry.Comment = err.Error()
+    entry.Timings, entry.Time = tr.timings(started, finished)
+    p.record(entry)
+    return nil, nil, err
+  }
+
+  entry.Response = harResponse(resp, decoded(resp.Header, body))
+  entry.Response.BodySize = len(body)
+  entry.Timings, entry.Time = tr.timings(started, finished)
+  if tr.remote != "" {
+    entry.ServerIPAddress, _, _ = net.SplitHostPort(tr.remote)
+  }
+  p.record(entry)
+  return resp, body, nil
+}
+
+// noResponse stands in for the response of a request that got none
+func noResponse() HARResponse {
+  return HARResponse{Cookies: []HARCookie{}, Headers: []HARNameValue{}, HeadersSize: -1, BodySize: -1}
+}
+
+// decoded undoes gzip content encoding so the HAR holds the readable body
+func decoded(h http.Header, body []byte) []byte {
+  if h.Get("Content-Encoding") != "gzip" {
+    return body
+  }
+  zr, err := gzip.NewReader(bytes.NewReader(body))
+  if err != nil {
+    return body
+  }
+  plain, err := ioutil.ReadAll(zr)
+  if err != nil {
+    return body
+  }
+  return plain
+}
+
+// traceTimes collects the moments httptrace reports for one round trip
+type traceTimes struct {
+  mu                        sync.Mutex
+  dnsStart, dnsDone         time.Time
+  connectStart, connectEnd  time.Time
+  tlsStart, tlsDone         time.Time
+  gotConn, wrote, firstByte time.Time
+  remote                    string
+}
+
+func (tt *traceTimes) set(t *time.Time) {
+  tt.mu.Lock()
+  defer tt.mu.Unlock()
+  if t.IsZero() {
+    *t = time.Now()
+  }
+}
+
+func (tt *traceTimes) trace() *httptrace.ClientTrace {
+  return &httptrace.ClientTrace{
+    DNSStart:             func(httptrace.DNSStartInfo) { tt.set(&tt.dnsStart) },
+    DNSDone:              func(httptrace.DNSDoneInfo) { tt.set(&tt.dnsDone) },
+    ConnectStart:         func(string, string) { tt.set(&tt.connectStart) },
+    ConnectDone:          func(string, string, error) { tt.set(&tt.connectEnd) },
+    TLSHandshakeStart:    func() { tt.set(&tt.tlsStart) },
+    TLSHandshakeDone:     func(tls.ConnectionState, error) { tt.set(&tt.tlsDone) },
+    WroteRequest:         func(httptrace.WroteRequestInfo) { tt.set(&tt.wrote) },
+    GotFirstResponseByte: func() { tt.set(&tt.firstByte) },
+    GotConn: func(info httptrace.GotConnInfo) {
+      tt.set(&tt.gotConn)
+      tt.mu.Lock()
+      tt.remote = info.Conn.RemoteAddr().String()
+      tt.mu.Unlock()
+    },
+  }
+}
+
+// timings turns the collected moments into HAR timings and their total
+func (tt *traceTimes) timings(started, finished time.Time) (HARTimings, float64) {
+  tt.mu.Lock()
+  defer tt.mu.Unlock()
+
+  phase := func(from, to time.Time) float64 {
+    if from.IsZero() || to.IsZero() {
+      return -1
+    }
+    return millis(to.Sub(from))
+  }
+  t := HARTimings{
+    DNS:     phase(tt.dnsStart, tt.dnsDone),
+    Connect: phase(tt.connectStart, tt.connectEnd),
+    SSL:     phase(tt.tlsStart, tt.tlsDone),
+    Send:    phase(tt.gotConn, tt.wrote),
+    Wait:    phase(tt.wrote, tt.firstByte),
+    Receive: phase(tt.firstByte, finished),
+  }
+  if t.SSL >= 0 {
+    t.Connect = phase(tt.connectStart, tt.tlsDone) // HAR counts the handshake as part of connecting
+  }
+
+  // whatever happened before the connection was ready and is not dns or connecting was spent blocked
+  t.Blocked = -1
+  if !tt.gotConn.IsZero() {
+    t.Blocked = millis(tt.gotConn.Sub(started))
+    for _, d := range []float64{t.DNS, t.Connect} {
+      if d > 0 {
+        t.Blocked -= d
+      }
+    }
+    if t.Blocked < 0 {
+      t.Blocked = 0
+    }
+  }
+
+  var total float64
+  for _, d := range []float64{t.Blocked, t.DNS, t.Connect, t.Send, t.Wait, t.Receive} {
+    if d > 0 {
+      total += d
+    }
+  }
+  if total == 0 {
+    total = millis(finished.Sub(started))
+  }
+  return t, total
+}
+
+func (p *Proxy) makeCA() (err error) {
+  if p.caKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader); err != nil {
+    return err
+  }
+  if p.leafKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader); err != nil {
+    return err
+  }
+  tmpl := &x509.Certificate{
+    SerialNumber:          serial(),
+    Subject:               pkix.Name{CommonName: "webdriver recording proxy CA"},
+    NotBefore:             time.Now().Add(-time.Hour),
+    NotAfter:              time.Now().Add(10 * 365 * 24 * time.Hour),
+    IsCA:                  true,
+    BasicConstraintsValid: true,
+    KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature,
+  }
+  der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &p.caKey.PublicKey, p.caKey)
+  if err != nil {
+    return err
+  }
+  p.CA, err = x509.ParseCertificate(der)
+  return err
+}
+
+// cert returns a certificate for host signed by the proxy's CA
+func (p *Proxy) cert(host string) (*tls.Certificate, error) {
+  p.mu.Lock()
+  defer p.mu.Unlock()
+  if c, ok := p.certs[host]; ok {
+    return c, nil
+  }
+
+  tmpl := &x509.Certificate{
+    SerialNumber: serial(),
+    Subject:      pkix.Name{CommonName: host},
+    NotBefore:    time.Now().Add(-time.Hour),
+    NotAfter:     time.Now().Add(365 * 24 * time.Hour),
+    KeyUsage:     x509.KeyUsageDigitalSignature,
+    ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+  }
+  if ip := net.ParseIP(host); ip != nil {
+    tmpl.IPAddresses = []net.IP{ip}
+  } else {
+    tmpl.DNSNames = []string{host}
+  }
+  der, err := x509.CreateCertificate(rand.Reader, tmpl, p.CA, &p.leafKey.PublicKey, p.caKey)
+  if err != nil {
+    return nil, err
+  }
+  c := &tls.Certificate{Certificate: [][]byte{der, p.CA.Raw}, PrivateKey: p.leafKey}
+  p.certs[host] = c
+  return c, nil
+}
+
+func serial() *big.Int {
+  n, _ := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 64))
+  return n
+}
+
+// RecordHAR writes the traffic the session's recording proxy sees during t
+// to network.har in the test's artifact directory (see CaptureOnFailure) and
+// logs the path. The session needs WithRecordingProxy
+func (s *Session) RecordHAR(t testing.TB) {
+  if s.Proxy == nil {
+    t.Logf("Not recording traffic, the session has no recording proxy (see WithRecordingProxy)")
+    return
+  }
+  start := s.Proxy.count()
+  t.Cleanup(func() {
+    dir := filepath.Join(s.artifactDir(), artifactName(t.Name()))
+    file := filepath.Join(dir, "network.har")
+    err := os.MkdirAll(dir, 0755)
+    if err == nil {
+      err = NewHAR(s.Proxy.since(start)).WriteFile(file)
+    }
+    if err != nil {
+      t.Logf("Cannot save the recorded traffic: %s", err)
+      return
+    }
+    t.Logf("artifact: %s", file)
+  })
+}
+
+// RecordHAR records the default session's traffic during t
+func RecordHAR(t testing.TB) {
+  DefaultSession().RecordHAR(t)
+}
@@ -0,0 +1,180 @@
+package webdriver
+
+import (
+  "code.grantmurray.com/webdriver/fakewd"
+  "compress/gzip"
+  "crypto/tls"
+  "crypto/x509"
+  "encoding/json"
+  "io/ioutil"
+  "net/http"
+  "net/http/httptest"
+  "net/url"
+  "path/filepath"
+  "strings"
+  "testing"
+)
+
+// startProxy starts a recording proxy and a client that goes through it
+func startProxy(t *testing.T) (*Proxy, *http.Client) {
+  p, err := StartProxy("")
+  if err != nil {
+    t.Fatalf("Cannot start the proxy: %s", err)
+  }
+  t.Cleanup(func() { p.Close() })
+
+  roots := x509.NewCertPool()
+  roots.AddCert(p.CA)
+  client := &http.Client{Transport: &http.Transport{
+    Proxy:             http.ProxyURL(&url.URL{Scheme: "http", Host: p.Addr}),
+    TLSClientConfig:   &tls.Config{RootCAs: roots},
+    DisableKeepAlives: true,
+  }}
+  return p, client
+}
+
+func backend(t *testing.T, tls bool) *httptest.Server {
+  h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+    switch r.URL.Path {
+    case "/login":
+      body, _ := ioutil.ReadAll(r.Body)
+      http.SetCookie(w, &http.Cookie{Name: "SessionToken", Value: "abc", Path: "/"})
+      w.Header().Set("Content-Type", "application/json")
+      w.Write([]byte(`{"echo":"` + string(body) + `"}`))
+    case "/zipped":
+      w.Header().Set("Content-Type", "text/plain")
+      w.Header().Set("Content-Encoding", "gzip")
+      zw := gzip.NewWriter(w)
+      zw.Write([]byte("hello"))
+      zw.Close()
+    default:
+      http.NotFound(w, r)
+    }
+  })
+  var srv *httptest.Server
+  if tls {
+    srv = httptest.NewTLSServer(h)
+  } else {
+    srv = httptest.NewServer(h)
+  }
+  t.Cleanup(srv.Close)
+  return srv
+}
+
+func Test_Proxy_http(t *testing.T) {
+  p, client := startProxy(t)
+  srv := backend(t, false)
+
+  resp, err := client.Post(srv.URL+"/login?next=profile", "application/x-www-form-urlencoded", strings.NewReader("Email=a%40b.c"))
+  if err != nil {
+    t.Fatalf("Cannot send through the proxy: %s", err)
+  }
+  body, _ := ioutil.ReadAll(resp.Body)
+  resp.Body.Close()
+  if string(body) != `{"echo":"Email=a%40b.c"}` {
+    t.Errorf("Expected the backend's answer, got %q", body)
+  }
+
+  entries := p.Entries()
+  if len(entries) != 1 {
+    t.Fatalf("Expected 1 entry, got %d", len(entries))
+  }
+  e := entries[0]
+  if e.Request.Method != "POST" || e.Request.URL != srv.URL+"/login?next=profile" {
+    t.Errorf("Expected POST %s/login?next=profile, got %s %s", srv.URL, e.Request.Method, e.Request.URL)
+  }
+  if len(e.Request.QueryString) != 1 || e.Request.QueryString[0] != (HARNameValue{"next", "profile"}) {
+    t.Errorf("Expected the query string next=profile, got %v", e.Request.QueryString)
+  }
+  if e.Request.PostData == nil || len(e.Request.PostData.Params) != 1 || e.Request.PostData.Params[0] != (HARNameValue{"Email", "a@b.c"}) {
+    t.Errorf("Expected the form field Email=a@b.c, got %+v", e.Request.PostData)
+  }
+  if e.Response.Status != 200 || e.Response.StatusText != "OK" || e.Response.Content.Text != string(body) {
+    t.Errorf("Expected 200 OK with the body, got %+v", e.Response)
+  }
+  if len(e.Response.Cookies) != 1 || e.Response.Cookies[0].Name != "SessionToken" {
+    t.Errorf("Expected the SessionToken cookie, got %v", e.Response.Cookies)
+  }
+  if e.Time <= 0 || e.Timings.Wait < 0 || e.ServerIPAddress != "127.0.0.1" {
+    t.Errorf("Expected timings and the server address, got %v %+v %q", e.Time, e.Timings, e.ServerIPAddress)
+  }
+}
+
+func Test_Proxy_https(t *testing.T) {
+  p, client := startProxy(t)
+  srv := backend(t, true)
+
+  resp, err := client.Get(srv.URL + "/zipped")
+  if err != nil {
+    t.Fatalf("Cannot send through the proxy: %s", err)
+  }
+  body, _ := ioutil.ReadAll(resp.Body)
+  resp.Body.Close()
+  if string(body) != "hello" {
+    t.Errorf("Expected the backend's answer, got %q", body)
+  }
+
+  entries := p.Entries()
+  if len(entries) != 1 {
+    t.Fatalf("Expected 1 entry, got %d", len(entries))
+  }
+  e := entries[0]
+  if !strings.HasPrefix(e.Request.URL, "https://") || e.Response.Content.Text != "hello" {
+    t.Errorf("Expected the https request with the unzipped body, got %s %q", e.Request.URL, e.Response.Content.Text)
+  }
+  if e.Timings.SSL < 0 {
+    t.Errorf("Expected the TLS handshake to be timed, got %+v", e.Timings)
+  }
+
+  p.Reset()
+  if len(p.Entries()) != 0 {
+    t.Errorf("Expected Reset to forget the entries")
+  }
+}
+
+func Test_RecordHAR(t *testing.T) {
+  p, client := startProxy(t)
+  srv := backend(t, false)
+  s := &Session{Proxy: p, Config: Config{ArtifactDir: t.TempDir()}}
+
+  client.Get(srv.URL + "/before")
+  tb := &recordingTB{TB: t, name: "Test_Login/good"}
+  s.RecordHAR(tb)
+  client.Get(srv.URL + "/during")
+  tb.finish()
+
+  file := filepath.Join(s.Config.ArtifactDir, "Test_Login_good", "network.har")
+  data, err := ioutil.ReadFile(file)
+  if err != nil {
+    t.Fatalf("Expected the HAR to be written: %s", err)
+  }
+  var har HAR
+  if err = json.Unmarshal(data, &har); err != nil {
+    t.Fatalf("Expected the HAR to be JSON: %s", err)
+  }
+  if har.Log.Version != "1.2" || len(har.Log.Entries) != 1 || har.Log.Entries[0].Request.URL != srv.URL+"/during" {
+    t.Errorf("Expected only the request made during the test, got %+v", har.Log)
+  }
+  if len(tb.logs) != 1 || tb.logs[0] != "artifact: "+file {
+    t.Errorf("Expected the path to be logged, got %q", tb.logs)
+  }
+}
+
+func Test_WithRecordingProxy(t *testing.T) {
+  srv := fakewd.NewServer()
+  defer srv.Close()
+  s, err := NewRemoteSession(WithURL(srv.URL), WithRecordingProxy())
+  if err != nil {
+    t.Fatalf("Cannot connect to fake server: %s", err)
+  }
+  defer s.Quit()
+
+  if s.Proxy == nil {
+    t.Fatalf("Expected the session to have a proxy")
+  }
+  caps := srv.Sessions()[0].Desired
+  proxy, _ := caps["proxy"].(map[string]interface{})
+  if proxy["httpProxy"] != s.Proxy.Addr || proxy["sslProxy"] != s.Proxy.Addr || caps["acceptInsecureCerts"] != true {
+    t.Errorf("Expected the browser to be sent through %s, got %v", s.Proxy.Addr, caps)
+  }
+}
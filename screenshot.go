@@ -0,0 +1,238 @@
+package webdriver
+
+import (
+  "bytes"
+  "encoding/base64"
+  "errors"
+  "fmt"
+  "github.com/sourcegraph/go-selenium"
+  "image"
+  "image/draw"
+  "image/png"
+  "math"
+)
+
+// rectScript measures an element relative to the viewport, optionally
+// scrolling it into view first. It returns [left, top, width, height, devicePixelRatio]
+const rectScript = `var e = arguments[0];
+if (arguments[1]) { e.scrollIntoView({block: "nearest", inline: "nearest"}); }
+var r = e.getBoundingClientRect();
+return [r.left, r.top, r.width, r.height, window.devicePixelRatio || 1];`
+
+// ScreenshotElement captures just the element found by loc, scrolling it into
+// view if needed. Like an action on an Element it first waits for the
+// session's BusyMarker. It uses the server's element screenshot command where
+// there is one and otherwise crops a screenshot of the viewport. The image is
+// also written to filename unless that is ""
+func (s *Session) ScreenshotElement(loc Locator, filename string) (image.Image, error) {
+  if err := s.WaitNotBusy(); err != nil {
+    return nil, err
+  }
+  e, err := s.Find(loc)
+  if err != nil {
+    return nil, err
+  }
+  img, err := s.nativeElementScreenshot(e, loc)
+  if errors.Is(err, ErrUnknownCommand) || errors.Is(err, ErrNoServerURL) {
+    img, err = s.croppedElementScreenshot(e, loc)
+  }
+  if err != nil {
+    return nil, err
+  }
+  if filename != "" {
+    if err = writePNG(filename, img); err != nil {
+      return img, err
+    }
+  }
+  return img, nil
+}
+
+// nativeElementScreenshot uses the element screenshot command, which selenium
+// has no method for. Elements that do not carry selenium's id count as a
+// server without the command
+func (s *Session) nativeElementScreenshot(e selenium.WebElement, loc Locator) (image.Image, error) {
+  id := hiddenID(e)
+  if id == "" {
+    return nil, &Error{Op: "screenshot", Locator: loc.String(), Kind: ErrUnknownCommand, Err: errors.New("element id unknown")}
+  }
+
+  var encoded string
+  if err := s.command("GET", "/element/"+id+"/screenshot", nil, &encoded); err != nil {
+    return nil, err
+  }
+  data, err := base64.StdEncoding.DecodeString(encoded)
+  if err != nil {
+    return nil, fmt.Errorf("Cannot decode element screenshot of %s: %w", loc, err)
+  }
+  return png.Decode(bytes.NewReader(data))
+}
+
+func (s *Session) croppedElementScreenshot(e selenium.WebElement, loc Locator) (image.Image, error) {
+  r, err := s.elementRect(e, loc, true)
+  if err != nil {
+    return nil, err
+  }
+
+  data, err := s.Drv.Screenshot()
+  if err != nil {
+    return nil, s.wrap("screenshot", nil, err)
+  }
+  shot, err := png.Decode(bytes.NewReader(data))
+  if err != nil {
+    return nil, fmt.Errorf("Cannot decode screenshot: %w", err)
+  }
+
+  r = r.Add(shot.Bounds().Min).Intersect(shot.Bounds())
+  if r.Empty() {
+    return nil, fmt.Errorf("Element %s is not inside the viewport", loc)
+  }
+  crop := image.NewRGBA(image.Rect(0, 0, r.Dx(), r.Dy()))
+  draw.Draw(crop, crop.Bounds(), shot, r.Min, draw.Src)
+  return crop, nil
+}
+
+func (s *Session) elementRect(e selenium.WebElement, loc Locator, scroll bool) (image.Rectangle, error) {
+  res, err := s.Drv.ExecuteScript(rectScript, []interface{}{e, scroll})
+  if err != nil {
+    return image.Rectangle{}, s.wrap("measure", &loc, err)
+  }
+  list, _ := res.([]interface{})
+  var v [5]float64
+  for i := range v {
+    ok := i < len(list)
+    if ok {
+      v[i], ok = list[i].(float64)
+    }
+    if !ok {
+      return image.Rectangle{}, fmt.Errorf("Cannot measure %s, got %v", loc, res)
+    }
+  }
+  scale := v[4]
+  return image.Rect(int(math.Floor(v[0]*scale)), int(math.Floor(v[1]*scale)),
+    int(math.Ceil((v[0]+v[2])*scale)), int(math.Ceil((v[1]+v[3])*scale))), nil
+}
+
+// ScreenshotElement captures the element found by loc on the default session
+func ScreenshotElement(loc Locator, filename string) (image.Image, error) {
+  return DefaultSession().ScreenshotElement(loc, filename)
+}
+
+// Scripts used by ScreenshotFullPage
+const (
+  // pageMetricsScript returns [scrollX, scrollY, viewport width, viewport height, document height, devicePixelRatio]
+  pageMetricsScript = `var d = document.documentElement;
+return [window.pageXOffset, window.pageYOffset, d.clientWidth, d.clientHeight,
+  Math.max(d.scrollHeight, document.body ? document.body.scrollHeight : 0), window.devicePixelRatio || 1];`
+
+  // scrollToScript scrolls and returns where the page actually ended up vertically
+  scrollToScript = `window.scrollTo(arguments[0], arguments[1]); return window.pageYOffset;`
+
+  // fixedScript hides (arguments[0] true) or shows again the fixed and sticky
+  // elements, which would otherwise appear in every capture
+  fixedScript = `var hide = arguments[0], n = 0, all = document.querySelectorAll("*");
+for (var i = 0; i < all.length; i++) {
+  var e = all[i];
+  if (hide) {
+    var pos = window.getComputedStyle(e).position;
+    if ((pos == "fixed" || pos == "sticky") && !e.hasAttribute("data-webdriver-hidden")) {
+      e.setAttribute("data-webdriver-hidden", e.style.visibility);
+      e.style.visibility = "hidden";
+      n++;
+    }
+  } else if (e.hasAttribute("data-webdriver-hidden")) {
+    e.style.visibility = e.getAttribute("data-webdriver-hidden");
+    e.removeAttribute("data-webdriver-hidden");
+    n++;
+  }
+}
+return n;`
+)
+
+// ScreenshotFullPage captures the whole length of the document rather than
+// just the viewport. It scrolls down a viewport at a time and stitches the
+// captures together. Fixed and sticky elements such as headers are shown in
+// the first capture only, so they appear once at the top. The scroll position
+// is put back afterwards. The image is also written to filename unless that is ""
+func (s *Session) ScreenshotFullPage(filename string) (img image.Image, err error) {
+  var m []float64
+  if err = s.script(pageMetricsScript, nil, &m); err != nil {
+    return nil, err
+  }
+  if len(m) < 6 || m[3] <= 0 {
+    return nil, fmt.Errorf("Cannot make sense of the page size %v", m)
+  }
+  scrollX, scrollY, viewH, docH, scale := m[0], m[1], m[3], m[4], m[5]
+
+  var hidden bool
+  defer func() {
+    var restored float64
+    if hidden {
+      s.script(fixedScript, []interface{}{false}, &restored)
+    }
+    if e := s.script(scrollToScript, []interface{}{scrollX, scrollY}, &restored); err == nil {
+      err = e
+    }
+  }()
+
+  var canvas *image.RGBA
+  for y := 0.0; ; y += viewH {
+    var at float64
+    if err = s.script(scrollToScript, []interface{}{0, y}, &at); err != nil {
+      return nil, err
+    }
+    shot, err := s.viewportShot()
+    if err != nil {
+      return nil, err
+    }
+
+    if canvas == nil {
+      if docH > viewH && float64(shot.Bounds().Dy()) >= docH*scale-1 {
+        // some drivers capture the whole page by themselves
+        img = shot
+        break
+      }
+      canvas = image.NewRGBA(image.Rect(0, 0, shot.Bounds().Dx(), int(math.Ceil(docH*scale))))
+    }
+
+    top := int(math.Round(at * scale))
+    r := image.Rect(0, top, shot.Bounds().Dx(), top+shot.Bounds().Dy())
+    draw.Draw(canvas, r, shot, shot.Bounds().Min, draw.Src)
+
+    if at+viewH >= docH || (y > 0 && at < y) {
+      img = canvas
+      break
+    }
+    if !hidden {
+      var n float64
+      if err = s.script(fixedScript, []interface{}{true}, &n); err != nil {
+        return nil, err
+      }
+      hidden = true
+    }
+  }
+
+  if filename != "" {
+    if err = writePNG(filename, img); err != nil {
+      return img, err
+    }
+  }
+  return img, nil
+}
+
+// viewportShot takes and decodes a screenshot
+func (s *Session) viewportShot() (image.Image, error) {
+  data, err := s.Drv.Screenshot()
+  if err != nil {
+    return nil, s.wrap("screenshot", nil, err)
+  }
+  shot, err := png.Decode(bytes.NewReader(data))
+  if err != nil {
+    return nil, fmt.Errorf("Cannot decode screenshot: %w", err)
+  }
+  return shot, nil
+}
+
+// ScreenshotFullPage captures the whole page on the default session
+func ScreenshotFullPage(filename string) (image.Image, error) {
+  return DefaultSession().ScreenshotFullPage(filename)
+}
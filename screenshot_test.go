@@ -0,0 +1,134 @@
+package webdriver
+
+import (
+  "code.grantmurray.com/webdriver/fakewd"
+  "image"
+  "image/color"
+  "image/draw"
+  "math"
+  "path/filepath"
+  "testing"
+)
+
+func Test_ScreenshotElement(t *testing.T) {
+  s, srv := newFakeSession(t)
+  page := loginPage(srv)
+  form := page.Find("form")
+  form.X, form.Y, form.Width, form.Height = 10, 20, 30, 15
+
+  // a 2x screen, the form is drawn red at twice its css size
+  red := color.RGBA{0xff, 0, 0, 0xff}
+  page.Scale = 2
+  shot := fakewd.Solid(1600, 1200, color.White)
+  for y := 40; y < 70; y++ {
+    for x := 20; x < 80; x++ {
+      shot.Set(x, y, red)
+    }
+  }
+  page.Screenshot = shot
+  s.Drv.Get(loginURL)
+
+  for _, native := range []bool{false, true} {
+    srv.ElementScreenshots = native
+    file := filepath.Join(t.TempDir(), "form.png")
+
+    img, err := s.ScreenshotElement(CSS("form"), file)
+    if err != nil {
+      t.Fatalf("ScreenshotElement (native %v) failed: %s", native, err)
+    }
+    if b := img.Bounds(); b.Dx() != 60 || b.Dy() != 30 {
+      t.Errorf("Expected a 60x30 image (native %v), got %v", native, b)
+    }
+    for _, p := range []image.Point{{0, 0}, {59, 29}} {
+      if c := color.RGBAModel.Convert(img.At(img.Bounds().Min.X+p.X, img.Bounds().Min.Y+p.Y)); c != red {
+        t.Errorf("Expected %v to be red (native %v), got %v", p, native, c)
+      }
+    }
+    if _, err = readPNG(file); err != nil {
+      t.Errorf("Expected the image to be saved: %s", err)
+    }
+  }
+
+  // the native command finds the element like any other find, and a session
+  // without a server url crops instead
+  srv.ElementScreenshots = true
+  synced := 0
+  s.Sync = Named("synced", func(*Session) (bool, string, error) {
+    synced++
+    return true, "", nil
+  })
+  if _, err := s.ScreenshotElement(CSS("form"), ""); err != nil || synced == 0 {
+    t.Errorf("Expected the native screenshot to sync first, got %v after %d syncs", err, synced)
+  }
+  plain := NewSession(s.Drv)
+  if img, err := plain.ScreenshotElement(CSS("form"), ""); err != nil || img.Bounds().Dx() != 60 {
+    t.Errorf("Expected a cropped screenshot without a server url, got %v", err)
+  }
+
+  srv.ElementScreenshots = false
+  form.X = 900
+  if _, err := s.ScreenshotElement(CSS("form"), ""); err == nil {
+    t.Errorf("Expected an error for an element outside the viewport")
+  }
+}
+
+func Test_ScreenshotFullPage(t *testing.T) {
+  s, srv := newFakeSession(t)
+  page := loginPage(srv)
+
+  // a 1500px document seen through a 600px viewport with a 50px fixed header
+  header := color.RGBA{0, 0, 0xff, 0xff}
+  doc := image.NewRGBA(image.Rect(0, 0, 800, 1500))
+  for y := 0; y < 1500; y++ {
+    for x := 0; x < 800; x++ {
+      doc.Set(x, y, color.RGBA{uint8(y / 6), 0, 0, 0xff})
+    }
+  }
+  scrollY, hidden := 100.0, false
+  render := func() {
+    view := image.NewRGBA(image.Rect(0, 0, 800, 600))
+    draw.Draw(view, view.Bounds(), doc, image.Pt(0, int(scrollY)), draw.Src)
+    if !hidden {
+      draw.Draw(view, image.Rect(0, 0, 800, 50), image.NewUniform(header), image.Point{}, draw.Src)
+    }
+    page.Screenshot = view
+  }
+  render()
+
+  srv.HandleScript("scrollHeight", func(b *fakewd.Browser, args []interface{}) (interface{}, error) {
+    return []float64{0, scrollY, 800, 600, 1500, 1}, nil
+  })
+  srv.HandleScript("scrollTo", func(b *fakewd.Browser, args []interface{}) (interface{}, error) {
+    scrollY = math.Max(0, math.Min(args[1].(float64), 900))
+    render()
+    return scrollY, nil
+  })
+  srv.HandleScript("data-webdriver-hidden", func(b *fakewd.Browser, args []interface{}) (interface{}, error) {
+    hidden = args[0].(bool)
+    render()
+    return 1, nil
+  })
+  s.Drv.Get(loginURL)
+
+  img, err := s.ScreenshotFullPage(filepath.Join(t.TempDir(), "full.png"))
+  if err != nil {
+    t.Fatalf("ScreenshotFullPage failed: %s", err)
+  }
+  if b := img.Bounds(); b.Dx() != 800 || b.Dy() != 1500 {
+    t.Fatalf("Expected an 800x1500 image, got %v", b)
+  }
+  for _, y := range []int{0, 49} {
+    if c := color.RGBAModel.Convert(img.At(10, y)); c != header {
+      t.Errorf("Expected the header at the top, row %d is %v", y, c)
+    }
+  }
+  for _, y := range []int{50, 599, 600, 620, 1200, 1499} {
+    want := color.RGBA{uint8(y / 6), 0, 0, 0xff}
+    if c := color.RGBAModel.Convert(img.At(10, y)); c != want {
+      t.Errorf("Expected row %d to be %v, got %v", y, want, c)
+    }
+  }
+  if scrollY != 100 || hidden {
+    t.Errorf("Expected the page to be put back, scrolled to %v with fixed elements hidden %v", scrollY, hidden)
+  }
+}
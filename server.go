@@ -0,0 +1,128 @@
+package webdriver
+
+import (
+  "fmt"
+  "net"
+  "net/http"
+  "os"
+  "os/exec"
+  "time"
+)
+
+// kinds of local server that StartServer knows how to launch
+const (
+  SeleniumServer = "selenium"     // path is selenium-server-standalone.jar, run with java
+  ChromeDriver   = "chromedriver" // path is the chromedriver binary
+  GeckoDriver    = "geckodriver"  // path is the geckodriver binary
+)
+
+// ServerStartTimeout is how long StartServer waits for /status to become healthy
+var ServerStartTimeout = 30 * time.Second
+
+// Server is a selenium server or browser driver process started by this package
+type Server struct {
+  Kind    string
+  URL     string // url to hand to selenium.NewRemote
+  LogFile string // stdout and stderr of the process end up here
+
+  cmd     *exec.Cmd
+  log     *os.File
+  done    chan error
+  stopped bool
+}
+
+// StartServer launches a local server of the given kind on a free port, with
+// its output going to logFile, and returns once its /status endpoint is healthy
+func StartServer(kind, path, logFile string) (srv *Server, err error) {
+  port, err := freePort()
+  if err != nil {
+    return nil, fmt.Errorf("Cannot find a free port for %s: %w", kind, err)
+  }
+
+  srv = &Server{Kind: kind, LogFile: logFile, done: make(chan error, 1)}
+
+  switch kind {
+  case SeleniumServer:
+    srv.cmd = exec.Command("java", "-jar", path, "-port", fmt.Sprint(port))
+    srv.URL = fmt.Sprintf("http://127.0.0.1:%d/wd/hub", port)
+  case ChromeDriver:
+    srv.cmd = exec.Command(path, fmt.Sprintf("--port=%d", port))
+    srv.URL = fmt.Sprintf("http://127.0.0.1:%d", port)
+  case GeckoDriver:
+    srv.cmd = exec.Command(path, "--port", fmt.Sprint(port))
+    srv.URL = fmt.Sprintf("http://127.0.0.1:%d", port)
+  default:
+    return nil, fmt.Errorf("Unknown server kind %q", kind)
+  }
+
+  if srv.log, err = os.Create(logFile); err != nil {
+    return nil, fmt.Errorf("Cannot create server log %s: %w", logFile, err)
+  }
+  srv.cmd.Stdout = srv.log
+  srv.cmd.Stderr = srv.log
+
+  if err = srv.cmd.Start(); err != nil {
+    srv.log.Close()
+    return nil, fmt.Errorf("Failed to start %s %s: %w", kind, path, err)
+  }
+  go func() { srv.done <- srv.cmd.Wait() }()
+
+  if err = srv.waitHealthy(ServerStartTimeout); err != nil {
+    srv.Stop()
+    return nil, err
+  }
+  return srv, nil
+}
+
+// waitHealthy polls /status until it answers 200 OK, the process dies or timeout passes
+func (srv *Server) waitHealthy(timeout time.Duration) error {
+  client := &http.Client{Timeout: time.Second}
+  deadline := time.Now().Add(timeout)
+
+  for time.Now().Before(deadline) {
+    select {
+    case err := <-srv.done:
+      srv.done <- err
+      return fmt.Errorf("%s exited before becoming healthy (%v), see %s", srv.Kind, err, srv.LogFile)
+    default:
+    }
+
+    res, err := client.Get(srv.URL + "/status")
+    if err == nil {
+      res.Body.Close()
+      if res.StatusCode == http.StatusOK {
+        return nil
+      }
+    }
+    time.Sleep(100 * time.Millisecond)
+  }
+  return &Error{Op: "start " + srv.Kind + " at " + srv.URL, Kind: ErrTimeout,
+    Err: fmt.Errorf("not healthy after %s, see %s", timeout, srv.LogFile)}
+}
+
+// Stop asks the server to exit, kills it if it does not, and closes its log
+func (srv *Server) Stop() error {
+  if srv.stopped {
+    return nil
+  }
+  srv.stopped = true
+  defer srv.log.Close()
+
+  srv.cmd.Process.Signal(os.Interrupt)
+  select {
+  case <-srv.done:
+  case <-time.After(5 * time.Second):
+    srv.cmd.Process.Kill()
+    <-srv.done
+  }
+  return nil
+}
+
+func freePort() (int, error) {
+  l, err := net.Listen("tcp", "127.0.0.1:0")
+  if err != nil {
+    return 0, err
+  }
+  defer l.Close()
+  return l.Addr().(*net.TCPAddr).Port, nil
+}
@@ -0,0 +1,82 @@
+package webdriver
+
+import (
+  "fmt"
+  "io/ioutil"
+  "net/http"
+  "os"
+  "path/filepath"
+  "strings"
+  "testing"
+)
+
+// TestMain lets the test binary stand in for a driver binary, see helperDriver
+func TestMain(m *testing.M) {
+  switch os.Getenv("WEBDRIVER_TEST_HELPER") {
+  case "healthy":
+    helperDriver()
+  case "crash":
+    fmt.Println("helper driver crashing on purpose")
+    os.Exit(3)
+  }
+  os.Exit(m.Run())
+}
+
+// helperDriver behaves like chromedriver just enough for StartServer
+func helperDriver() {
+  var port string
+  for _, a := range os.Args[1:] {
+    if strings.HasPrefix(a, "--port=") {
+      port = a[len("--port="):]
+    }
+  }
+  fmt.Println("helper driver listening on", port)
+  http.HandleFunc("/status", func(w http.ResponseWriter, r *http.Request) {
+    fmt.Fprint(w, `{"status":0,"value":{}}`)
+  })
+  http.ListenAndServe("127.0.0.1:"+port, nil)
+  os.Exit(0)
+}
+
+func Test_StartServer_healthy(t *testing.T) {
+  os.Setenv("WEBDRIVER_TEST_HELPER", "healthy")
+  defer os.Unsetenv("WEBDRIVER_TEST_HELPER")
+
+  logFile := filepath.Join(t.TempDir(), "driver.log")
+  srv, err := StartServer(ChromeDriver, os.Args[0], logFile)
+  if err != nil {
+    t.Fatalf("StartServer failed: %s", err)
+  }
+
+  res, err := http.Get(srv.URL + "/status")
+  if err != nil {
+    t.Fatalf("Server at %s not reachable: %s", srv.URL, err)
+  }
+  res.Body.Close()
+
+  srv.Stop()
+  if _, err = http.Get(srv.URL + "/status"); err == nil {
+    t.Errorf("Server at %s still answering after Stop", srv.URL)
+  }
+
+  logged, _ := ioutil.ReadFile(logFile)
+  if !strings.Contains(string(logged), "helper driver listening") {
+    t.Errorf("Expected driver output in %s, got %q", logFile, logged)
+  }
+}
+
+func Test_StartServer_crash(t *testing.T) {
+  os.Setenv("WEBDRIVER_TEST_HELPER", "crash")
+  defer os.Unsetenv("WEBDRIVER_TEST_HELPER")
+
+  _, err := StartServer(ChromeDriver, os.Args[0], filepath.Join(t.TempDir(), "driver.log"))
+  if err == nil || !strings.Contains(err.Error(), "exited before becoming healthy") {
+    t.Fatalf("Expected StartServer to report the early exit, got %v", err)
+  }
+}
+
+func Test_StartServer_unknown_kind(t *testing.T) {
+  if _, err := StartServer("opera", "/bin/true", filepath.Join(t.TempDir(), "x.log")); err == nil {
+    t.Fatalf("Expected an error for an unknown server kind")
+  }
+}
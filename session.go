@@ -0,0 +1,219 @@
+package webdriver
+
+import (
+  "errors"
+  "fmt"
+  "github.com/sourcegraph/go-selenium"
+  "io/ioutil"
+  "time"
+)
+
+// Session is one browser together with the wait state that belongs to it.
+// Sessions do not share anything, so several browsers can be driven at once
+type Session struct {
+  Drv             selenium.WebDriver
+  Config          Config
+  InitialWait     time.Duration
+  WaitForTimedOut bool
+
+  // WaitUntil settings, see WaitUntil
+  WaitTimeout     time.Duration
+  PollInterval    time.Duration
+  PollBackoff     float64 // interval multiplier after each poll, values <= 1 mean a fixed interval
+  MaxPollInterval time.Duration
+
+  // Sync is waited for before every Find, nil for no waiting. See WithAngularSync
+  Sync Condition
+  // Busy is waited for after opening pages and acting on elements, nil for none. See BusyMarker
+  Busy *BusyMarker
+
+  Proxy *Proxy // the recording proxy, nil unless the session was made WithRecordingProxy
+
+  server *Server // non-nil when the session launched its own server
+  id     string  // server side session id, see sessionID
+
+  waiting int // WaitUntil calls under way, Find does not sync inside them
+
+  console     []LogEntry // collected by Console
+  consoleHook bool       // the server has no browser log, Console reads an injected hook instead
+}
+
+// NewSession wraps an already connected driver in a Session. What selenium
+// has no method for, like Cookies or Console, is sent to the server directly
+// and needs Config.URL set to the server the driver talks to
+func NewSession(drv selenium.WebDriver) *Session {
+  return &Session{
+    Drv:             drv,
+    InitialWait:     InitialWait,
+    WaitTimeout:     DefaultWaitTimeout,
+    PollInterval:    DefaultPollInterval,
+    PollBackoff:     1,
+    MaxPollInterval: DefaultMaxPollInterval,
+  }
+}
+
+// NewRemoteSession establishes a new connection to the remote selenium server,
+// see NewConfig for how the server and the browser are chosen
+func NewRemoteSession(opts ...Option) (*Session, error) {
+  cfg, err := NewConfig(opts...)
+  if err != nil {
+    return nil, err
+  }
+
+  var srv *Server
+  if cfg.Server != "" {
+    if srv, err = StartServer(cfg.Server, cfg.ServerPath, cfg.ServerLog); err != nil {
+      return nil, err
+    }
+    cfg.URL = srv.URL
+  }
+
+  caps := cfg.SeleniumCapabilities()
+  var proxy *Proxy
+  if cfg.Record {
+    if proxy, err = StartProxy(""); err != nil {
+      if srv != nil {
+        srv.Stop()
+      }
+      return nil, fmt.Errorf("Cannot start the recording proxy: %w", err)
+    }
+    if cfg.Proxy != "" {
+      proxy.UseUpstream(cfg.Proxy)
+    }
+    caps["proxy"] = proxyCapability(proxy.Addr)
+    caps["acceptSslCerts"] = true
+    caps["acceptInsecureCerts"] = true
+  }
+
+  drv, err := selenium.NewRemote(caps, cfg.URL)
+  if err != nil {
+    if srv != nil {
+      srv.Stop()
+    }
+    if proxy != nil {
+      proxy.Close()
+    }
+    return nil, &Error{Op: "selenium.NewRemote for " + cfg.URL, Kind: ErrSessionNotCreated, Err: err}
+  }
+  s := NewSession(drv)
+  s.id = driverSessionID(drv)
+  s.Config = cfg
+  s.Sync = cfg.Sync
+  s.Busy = cfg.Busy
+  s.Proxy = proxy
+  s.server = srv
+  return s, nil
+}
+
+// Quit ends the browser session and stops the local server and proxy if the session started them
+func (s *Session) Quit() error {
+  err := s.Drv.Quit()
+  if s.server != nil {
+    s.server.Stop()
+  }
+  if s.Proxy != nil {
+    s.Proxy.Close()
+  }
+  return err
+}
+
+// ScreenshotToFile takes a screenshot and writes it to filename
+func (s *Session) ScreenshotToFile(filename string) (err error) {
+
+  screenshot, err := s.Drv.Screenshot()
+  if err != nil {
+    return fmt.Errorf("Error during ScreenshotToFile using filename %s: %w", filename, s.wrap("screenshot", nil, err))
+  }
+  return ioutil.WriteFile(filename, screenshot, 0644)
+}
+
+// ElementToVanish is a WaitFor function. As long as the element is present
+// waiting continues, once the element cannot be found waiting stops.
+// The argument is a CSS selector string or a Locator
+func (s *Session) ElementToVanish(sel []interface{}) bool {
+  loc, err := locatorArg(sel)
+  if err != nil {
+    return false
+  }
+  _, err = s.Find(loc)
+  return errors.Is(err, ErrNoSuchElement)
+}
+
+// ElementToAppear is a WaitFor function. As long as the element is absent
+// waiting continues, once the element is found waiting stops.
+// The argument is a CSS selector string or a Locator
+func (s *Session) ElementToAppear(sel []interface{}) bool {
+  loc, err := locatorArg(sel)
+  if err != nil {
+    return false
+  }
+  _, err = s.Find(loc)
+  if err == nil {
+    return true
+  }
+  return false
+}
+
+// DocumentIsReady can be used as a WaitFor isReady parameter, it is true once
+// document.readyState is complete. New code should prefer DocumentReady
+func (s *Session) DocumentIsReady(unused []interface{}) bool {
+  result, err := s.Drv.ExecuteScript("return document.readyState", nil)
+  if err == nil && result == "complete" {
+    return true
+  }
+  return false
+}
+
+// UrlIsCurrent can be used as a WaitFor isReady parameter, arguments that
+// are not strings never match
+func (s *Session) UrlIsCurrent(urls []interface{}) bool {
+  cur, err := s.Drv.CurrentURL()
+  if err != nil {
+    return false
+  }
+
+  for i := 0; i < len(urls); i++ {
+    if url, ok := urls[i].(string); ok && cur == url {
+      return true
+    }
+  }
+  return false
+}
+
+// WaitFor sleeps until isReady() returns true unless it waits as long as timeoutAfter then it sets s.WaitForTimedOut to true and returns.
+// New code should prefer WaitUntil which reports timeouts as errors
+func (s *Session) WaitFor(timeoutAfter time.Duration, isReady func([]interface{}) bool, args ...interface{}) {
+  time.Sleep(s.InitialWait)
+
+  if s.InitialWait >= timeoutAfter {
+    s.WaitForTimedOut = true
+    return
+  }
+
+  const ITERATIONS = 10
+  var sleepDuration time.Duration = (timeoutAfter - s.InitialWait) / ITERATIONS
+
+  for i := 0; i <= ITERATIONS; i++ {
+    if isReady(args) {
+      s.WaitForTimedOut = false
+      return
+    }
+    time.Sleep(sleepDuration)
+  }
+
+  s.WaitForTimedOut = true
+}
+
+// FindNamedElements returns a map of elements with a memeber for each name in names
+func (s *Session) FindNamedElements(names []string) (elements map[string]selenium.WebElement, err error) {
+  locs := make(map[string]Locator, len(names))
+  for _, n := range names {
+    locs[n] = Name(n)
+  }
+  return s.FindElementsBy(locs)
+}
+
+// FetchText returns the msg text in an element ByCSSSelector sel
+func (s *Session) FetchText(sel string) (msg string, err error) {
+  return s.FetchTextBy(CSS(sel))
+}
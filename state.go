@@ -0,0 +1,131 @@
+package webdriver
+
+import (
+  "encoding/json"
+  "fmt"
+  "io/ioutil"
+)
+
+// BrowserState is what keeps a user logged in: the cookies and web storage
+// of one or more origins. Save it after logging in once and load it in the
+// tests that only need to be logged in:
+//
+//   // in Test_Login_Setup, after a successful login
+//   webdriver.SaveState("testdata/logged-in.json")
+//
+//   // in other tests
+//   webdriver.LoadState("testdata/logged-in.json")
+//
+// The file holds live session tokens, keep it out of version control
+type BrowserState struct {
+  URL     string        `json:"url"` // the page the state was taken on, SetState ends there
+  Origins []OriginState `json:"origins"`
+}
+
+// OriginState is the part of a BrowserState belonging to one origin
+type OriginState struct {
+  URL     string          `json:"url"` // a page of the origin, opened to restore its state
+  Cookies []Cookie        `json:"cookies"`
+  Storage StorageSnapshot `json:"storage"`
+}
+
+// State takes the state of the current page's origin and, if urls are
+// given, of the origins of those pages, which it opens in turn before
+// returning to the current page
+func (s *Session) State(urls ...string) (state BrowserState, err error) {
+  if state.URL, err = s.Drv.CurrentURL(); err != nil {
+    return state, s.wrap("get current url", nil, err)
+  }
+  take := func(url string) error {
+    o := OriginState{URL: url}
+    if o.Cookies, err = s.Cookies(); err != nil {
+      return err
+    }
+    if o.Storage, err = s.SnapshotStorage(); err != nil {
+      return err
+    }
+    state.Origins = append(state.Origins, o)
+    return nil
+  }
+
+  if err = take(state.URL); err != nil || len(urls) == 0 {
+    return state, err
+  }
+  for _, url := range urls {
+    if err = s.keepConsole(func() error { return s.wrap("open "+url, nil, s.Drv.Get(url)) }); err != nil {
+      return state, err
+    }
+    if err = take(url); err != nil {
+      return state, err
+    }
+  }
+  return state, s.keepConsole(func() error { return s.wrap("return to "+state.URL, nil, s.Drv.Get(state.URL)) })
+}
+
+// SetState opens a page of each origin in state, replaces its cookies and
+// storage with the saved ones and finally opens the page the state was taken on
+func (s *Session) SetState(state BrowserState) error {
+  for _, o := range state.Origins {
+    if err := s.keepConsole(func() error { return s.wrap("open "+o.URL, nil, s.Drv.Get(o.URL)) }); err != nil {
+      return err
+    }
+    if err := s.DeleteAllCookies(); err != nil {
+      return err
+    }
+    for _, c := range o.Cookies {
+      if c.Expired() {
+        continue
+      }
+      if err := s.SetCookie(c); err != nil {
+        return fmt.Errorf("Cannot restore cookie %s for %s: %w", c.Name, o.URL, err)
+      }
+    }
+    if err := s.RestoreStorage(o.Storage); err != nil {
+      return err
+    }
+  }
+  if state.URL == "" {
+    return nil
+  }
+  return s.keepConsole(func() error { return s.wrap("open "+state.URL, nil, s.Drv.Get(state.URL)) })
+}
+
+// SaveState writes the State of the current page's origin, and of the
+// origins of urls, to filename as JSON
+func (s *Session) SaveState(filename string, urls ...string) error {
+  state, err := s.State(urls...)
+  if err != nil {
+    return fmt.Errorf("Cannot save the browser state: %w", err)
+  }
+  data, err := json.MarshalIndent(state, "", "  ")
+  if err != nil {
+    return err
+  }
+  return ioutil.WriteFile(filename, data, 0600)
+}
+
+// LoadState reads a state written by SaveState and sets it, see SetState
+func (s *Session) LoadState(filename string) error {
+  data, err := ioutil.ReadFile(filename)
+  if err != nil {
+    return err
+  }
+  var state BrowserState
+  if err = json.Unmarshal(data, &state); err != nil {
+    return fmt.Errorf("Cannot read the browser state in %s: %w", filename, err)
+  }
+  if err = s.SetState(state); err != nil {
+    return fmt.Errorf("Cannot load the browser state from %s: %w", filename, err)
+  }
+  return nil
+}
+
+// SaveState saves the default session's browser state
+func SaveState(filename string, urls ...string) error {
+  return DefaultSession().SaveState(filename, urls...)
+}
+
+// LoadState loads a saved browser state into the default session
+func LoadState(filename string) error {
+  return DefaultSession().LoadState(filename)
+}
@@ -0,0 +1,57 @@
+package webdriver
+
+import (
+  "code.grantmurray.com/webdriver/fakewd"
+  "path/filepath"
+  "reflect"
+  "testing"
+)
+
+func Test_SaveState(t *testing.T) {
+  s, srv := newFakeSession(t)
+  loginPage(srv)
+  s.Drv.Get(loginURL)
+  b := srv.Sessions()[0]
+  b.SetCookie(fakewd.Cookie{Name: "SessionToken", Value: "abc", Path: "/"})
+  b.LocalStorage = map[string]string{"SessionToken": "abc"}
+
+  file := filepath.Join(t.TempDir(), "state.json")
+  if err := s.SaveState(file); err != nil {
+    t.Fatalf("Cannot save the state: %s", err)
+  }
+
+  b.Cookies = []fakewd.Cookie{{Name: "stale", Value: "x"}}
+  b.LocalStorage = nil
+  s.Drv.Get("https://example.com/")
+
+  if err := s.LoadState(file); err != nil {
+    t.Fatalf("Cannot load the state: %s", err)
+  }
+  if b.URL != loginURL {
+    t.Errorf("Expected to end on %s, got %s", loginURL, b.URL)
+  }
+  if !reflect.DeepEqual(b.Cookies, []fakewd.Cookie{{Name: "SessionToken", Value: "abc", Path: "/"}}) {
+    t.Errorf("Expected only the saved cookie, got %+v", b.Cookies)
+  }
+  if !reflect.DeepEqual(b.LocalStorage, map[string]string{"SessionToken": "abc"}) {
+    t.Errorf("Expected the saved localStorage, got %v", b.LocalStorage)
+  }
+}
+
+func Test_State_origins(t *testing.T) {
+  s, srv := newFakeSession(t)
+  loginPage(srv)
+  s.Drv.Get(loginURL)
+  b := srv.Sessions()[0]
+
+  state, err := s.State("https://api.plog.org/")
+  if err != nil {
+    t.Fatalf("Cannot take the state: %s", err)
+  }
+  if len(state.Origins) != 2 || state.Origins[1].Storage.Origin != "https://api.plog.org" {
+    t.Errorf("Expected the state of both origins, got %+v", state)
+  }
+  if b.URL != loginURL {
+    t.Errorf("Expected to be back on %s, got %s", loginURL, b.URL)
+  }
+}
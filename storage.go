@@ -0,0 +1,159 @@
+package webdriver
+
+import (
+  "fmt"
+  "sort"
+)
+
+// storageScript does one operation on window[area]. items and restore also
+// carry the page's origin, since storage belongs to an origin
+const storageScript = `var area = window[arguments[0]], op = arguments[1], key = arguments[2], value = arguments[3];
+var items = function() {
+  var out = {};
+  for (var i = 0; i < area.length; i++) {
+    var k = area.key(i);
+    out[k] = area.getItem(k);
+  }
+  return out;
+};
+switch (op) {
+case "get": return area.getItem(key);
+case "set": area.setItem(key, value); return null;
+case "remove": area.removeItem(key); return null;
+case "clear": area.clear(); return null;
+case "items": return {origin: location.origin, items: items()};
+case "restore":
+  area.clear();
+  for (var k in value) { area.setItem(k, value[k]); }
+  return null;
+}
+throw new Error("unknown storage operation " + op);`
+
+// Storage is localStorage or sessionStorage of the current page's origin
+type Storage struct {
+  s    *Session
+  area string
+}
+
+// LocalStorage returns the current page's localStorage
+func (s *Session) LocalStorage() Storage {
+  return Storage{s, "localStorage"}
+}
+
+// SessionStorage returns the current page's sessionStorage
+func (s *Session) SessionStorage() Storage {
+  return Storage{s, "sessionStorage"}
+}
+
+func (st Storage) run(op, key string, value interface{}, result interface{}) error {
+  if result == nil {
+    result = new(interface{})
+  }
+  err := st.s.script(storageScript, []interface{}{st.area, op, key, value}, result)
+  if err != nil {
+    return fmt.Errorf("%s %s: %w", st.area, op, err)
+  }
+  return nil
+}
+
+// Get returns the value stored under key and whether there is one
+func (st Storage) Get(key string) (string, bool, error) {
+  var value *string
+  if err := st.run("get", key, nil, &value); err != nil || value == nil {
+    return "", false, err
+  }
+  return *value, true, nil
+}
+
+// Set stores value under key
+func (st Storage) Set(key, value string) error {
+  return st.run("set", key, value, nil)
+}
+
+// Remove deletes key, it is not an error if it is not there
+func (st Storage) Remove(key string) error {
+  return st.run("remove", key, nil, nil)
+}
+
+// Clear deletes every key
+func (st Storage) Clear() error {
+  return st.run("clear", "", nil, nil)
+}
+
+// Items returns everything stored
+func (st Storage) Items() (map[string]string, error) {
+  _, items, err := st.items()
+  return items, err
+}
+
+func (st Storage) items() (origin string, items map[string]string, err error) {
+  var result struct {
+    Origin string            `json:"origin"`
+    Items  map[string]string `json:"items"`
+  }
+  err = st.run("items", "", nil, &result)
+  return result.Origin, result.Items, err
+}
+
+// Keys returns the stored keys, sorted
+func (st Storage) Keys() ([]string, error) {
+  items, err := st.Items()
+  keys := make([]string, 0, len(items))
+  for k := range items {
+    keys = append(keys, k)
+  }
+  sort.Strings(keys)
+  return keys, err
+}
+
+// StorageSnapshot is the localStorage and sessionStorage of one origin
+type StorageSnapshot struct {
+  Origin  string            `json:"origin"`
+  Local   map[string]string `json:"localStorage"`
+  Session map[string]string `json:"sessionStorage"`
+}
+
+// SnapshotStorage copies the web storage of the current page's origin
+func (s *Session) SnapshotStorage() (snap StorageSnapshot, err error) {
+  if snap.Origin, snap.Local, err = s.LocalStorage().items(); err != nil {
+    return snap, err
+  }
+  _, snap.Session, err = s.SessionStorage().items()
+  return snap, err
+}
+
+// RestoreStorage replaces the web storage of the current page's origin with
+// snap. The browser has to be on a page of snap's origin
+func (s *Session) RestoreStorage(snap StorageSnapshot) error {
+  origin, _, err := s.LocalStorage().items()
+  if err != nil {
+    return err
+  }
+  if snap.Origin != "" && origin != snap.Origin {
+    return fmt.Errorf("Cannot restore the storage of %s on a page of %s, open a page of that origin first", snap.Origin, origin)
+  }
+  if err = s.LocalStorage().run("restore", "", snap.Local, nil); err != nil {
+    return err
+  }
+  return s.SessionStorage().run("restore", "", snap.Session, nil)
+}
+
+// LocalStorage returns the default session's localStorage
+func LocalStorage() Storage {
+  return DefaultSession().LocalStorage()
+}
+
+// SessionStorage returns the default session's sessionStorage
+func SessionStorage() Storage {
+  return DefaultSession().SessionStorage()
+}
+
+// SnapshotStorage copies the default session's web storage
+func SnapshotStorage() (StorageSnapshot, error) {
+  return DefaultSession().SnapshotStorage()
+}
+
+// RestoreStorage replaces the default session's web storage
+func RestoreStorage(snap StorageSnapshot) error {
+  return DefaultSession().RestoreStorage(snap)
+}
@@ -0,0 +1,68 @@
+package webdriver
+
+import (
+  "reflect"
+  "testing"
+)
+
+func Test_Storage(t *testing.T) {
+  s, srv := newFakeSession(t)
+  loginPage(srv)
+  s.Drv.Get(loginURL)
+  b := srv.Sessions()[0]
+
+  local := s.LocalStorage()
+  if _, found, err := local.Get("SessionToken"); err != nil || found {
+    t.Errorf("Expected no SessionToken yet, got %v %v", found, err)
+  }
+  if err := local.Set("SessionToken", "abc"); err != nil {
+    t.Fatalf("Cannot set an item: %s", err)
+  }
+  local.Set("lang", "en")
+  if v, found, err := local.Get("SessionToken"); err != nil || !found || v != "abc" {
+    t.Errorf("Expected SessionToken abc, got %q %v %v", v, found, err)
+  }
+  if keys, err := local.Keys(); err != nil || !reflect.DeepEqual(keys, []string{"SessionToken", "lang"}) {
+    t.Errorf("Expected both keys, got %v %v", keys, err)
+  }
+  if err := local.Remove("lang"); err != nil || len(b.LocalStorage) != 1 {
+    t.Errorf("Expected lang removed, got %v %v", b.LocalStorage, err)
+  }
+
+  s.SessionStorage().Set("step", "2")
+  if len(b.SessionStorage) != 1 || len(b.LocalStorage) != 1 {
+    t.Errorf("Expected the areas to be separate, got %v and %v", b.LocalStorage, b.SessionStorage)
+  }
+  if err := s.SessionStorage().Clear(); err != nil || len(b.SessionStorage) != 0 {
+    t.Errorf("Expected sessionStorage cleared, got %v %v", b.SessionStorage, err)
+  }
+}
+
+func Test_SnapshotStorage(t *testing.T) {
+  s, srv := newFakeSession(t)
+  loginPage(srv)
+  s.Drv.Get(loginURL)
+  b := srv.Sessions()[0]
+  b.LocalStorage = map[string]string{"SessionToken": "abc"}
+  b.SessionStorage = map[string]string{"step": "2"}
+
+  snap, err := s.SnapshotStorage()
+  want := StorageSnapshot{"https://plog.org:8004", map[string]string{"SessionToken": "abc"}, map[string]string{"step": "2"}}
+  if err != nil || !reflect.DeepEqual(snap, want) {
+    t.Fatalf("Expected %+v, got %+v %v", want, snap, err)
+  }
+
+  b.LocalStorage = map[string]string{"other": "x"}
+  b.SessionStorage = nil
+  if err = s.RestoreStorage(snap); err != nil {
+    t.Fatalf("Cannot restore: %s", err)
+  }
+  if !reflect.DeepEqual(b.LocalStorage, snap.Local) || !reflect.DeepEqual(b.SessionStorage, snap.Session) {
+    t.Errorf("Expected the snapshot back, got %v and %v", b.LocalStorage, b.SessionStorage)
+  }
+
+  snap.Origin = "https://example.com"
+  if err = s.RestoreStorage(snap); err == nil {
+    t.Errorf("Expected restoring another origin's storage to fail")
+  }
+}
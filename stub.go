@@ -0,0 +1,189 @@
+package webdriver
+
+import (
+  "errors"
+  "net/http"
+  "regexp"
+  "strings"
+  "sync"
+  "testing"
+  "time"
+)
+
+// ErrNoProxy is returned by what needs a recording proxy when the session has none, see WithRecordingProxy
+var ErrNoProxy = errors.New("session has no recording proxy")
+
+// errAborted makes the proxy drop the browser's connection without an answer
+var errAborted = errors.New("connection aborted by stub")
+
+// Stub is what the proxy does with the requests a route matches instead of
+// passing them on:
+//
+//   Stub{Status: 400, Body: []byte(`{"Message":"Verification failed"}`)} // canned answer
+//   Stub{Status: 503}                                                      // error status, empty body
+//   Stub{Delay: 2 * time.Second}                                           // latency, then the real server answers
+//   Stub{Abort: true}                                                      // connection dropped, a network error to the page
+type Stub struct {
+  Status int           // 0 passes the request on to the server after Delay
+  Header http.Header   // Content-Type defaults to application/json when there is a Body
+  Body   []byte
+  Delay  time.Duration // waited before answering or passing on
+  Abort  bool          // close the connection instead of answering
+}
+
+// JSONStub is a canned answer with a JSON body
+func JSONStub(status int, body string) Stub {
+  return Stub{Status: status, Header: http.Header{"Content-Type": {"application/json"}}, Body: []byte(body)}
+}
+
+// route is a registered Stub together with what it matches
+type route struct {
+  method  string // "" or "*" match any method
+  pattern *regexp.Regexp
+  stub    Stub
+  hits    int
+}
+
+// routes are kept newest first so a later registration overrides an earlier one
+type routes struct {
+  mu   sync.Mutex
+  list []*route
+}
+
+// globPattern turns a URL pattern where * matches any run of characters,
+// including slashes, into an anchored regular expression
+func globPattern(pattern string) (*regexp.Regexp, error) {
+  parts := strings.Split(pattern, "*")
+  for i, part := range parts {
+    parts[i] = regexp.QuoteMeta(part)
+  }
+  return regexp.Compile("^" + strings.Join(parts, ".*") + "$")
+}
+
+func (rs *routes) add(method, pattern string, stub Stub) (*route, error) {
+  re, err := globPattern(pattern)
+  if err != nil {
+    return nil, err
+  }
+  rt := &route{method: strings.ToUpper(method), pattern: re, stub: stub}
+  rs.mu.Lock()
+  defer rs.mu.Unlock()
+  rs.list = append([]*route{rt}, rs.list...)
+  return rt, nil
+}
+
+func (rs *routes) remove(rt *route) {
+  rs.mu.Lock()
+  defer rs.mu.Unlock()
+  for i, r := range rs.list {
+    if r == rt {
+      rs.list = append(rs.list[:i:i], rs.list[i+1:]...)
+      return
+    }
+  }
+}
+
+// match finds the stub for a request and counts the hit
+func (rs *routes) match(method, url string) (Stub, bool) {
+  rs.mu.Lock()
+  defer rs.mu.Unlock()
+  for _, rt := range rs.list {
+    if (rt.method == "" || rt.method == "*" || rt.method == method) && rt.pattern.MatchString(url) {
+      rt.hits++
+      return rt.stub, true
+    }
+  }
+  return Stub{}, false
+}
+
+// Route answers requests with the given method ("" for any) whose full URL
+// matches pattern, where * stands for anything:
+//
+//   p.Route("POST", "*/api/verify*", webdriver.JSONStub(400, `{"Message":"Verification failed"}`))
+//
+// Routes registered later take precedence. The returned function removes the route
+func (p *Proxy) Route(method, pattern string, stub Stub) (remove func(), err error) {
+  rt, err := p.routes.add(method, pattern, stub)
+  if err != nil {
+    return nil, err
+  }
+  return func() { p.routes.remove(rt) }, nil
+}
+
+// ClearRoutes removes every route
+func (p *Proxy) ClearRoutes() {
+  p.routes.mu.Lock()
+  defer p.routes.mu.Unlock()
+  p.routes.list = nil
+}
+
+// stubbed applies a matching route to r. It reports whether the proxy should
+// answer itself with resp rather than pass r on
+func (p *Proxy) stubbed(r *http.Request) (resp *http.Response, body []byte, handled bool, err error) {
+  stub, ok := p.routes.match(r.Method, r.URL.String())
+  if !ok {
+    return nil, nil, false, nil
+  }
+  if stub.Delay > 0 {
+    select {
+    case <-time.After(stub.Delay):
+    case <-r.Context().Done():
+      return nil, nil, true, r.Context().Err()
+    }
+  }
+  if stub.Abort {
+    return nil, nil, true, errAborted
+  }
+  if stub.Status == 0 {
+    return nil, nil, false, nil
+  }
+
+  header := http.Header{}
+  for name, values := range stub.Header {
+    header[name] = append([]string(nil), values...)
+  }
+  if len(stub.Body) > 0 && header.Get("Content-Type") == "" {
+    header.Set("Content-Type", "application/json")
+  }
+  resp = &http.Response{
+    Status:     http.StatusText(stub.Status),
+    StatusCode: stub.Status,
+    Proto:      "HTTP/1.1",
+    ProtoMajor: 1,
+    ProtoMinor: 1,
+    Header:     header,
+    Request:    r,
+  }
+  return resp, stub.Body, true, nil
+}
+
+// Route registers a stub on the session's recording proxy for the rest of
+// the session, see Proxy.Route
+func (s *Session) Route(method, pattern string, stub Stub) (remove func(), err error) {
+  if s.Proxy == nil {
+    return nil, ErrNoProxy
+  }
+  return s.Proxy.Route(method, pattern, stub)
+}
+
+// RouteFor registers a stub that is removed when t finishes. Problems are
+// reported as t.Fatalf so a test can simply call
+//
+//   webdriver.RouteFor(t, "POST", "*/api/verify*", webdriver.Stub{Status: 500})
+func (s *Session) RouteFor(t testing.TB, method, pattern string, stub Stub) {
+  remove, err := s.Route(method, pattern, stub)
+  if err != nil {
+    t.Fatalf("Cannot route %s %s: %s", method, pattern, err)
+  }
+  t.Cleanup(remove)
+}
+
+// Route registers a stub on the default session's proxy
+func Route(method, pattern string, stub Stub) (func(), error) {
+  return DefaultSession().Route(method, pattern, stub)
+}
+
+// RouteFor registers a stub on the default session's proxy for the duration of t
+func RouteFor(t testing.TB, method, pattern string, stub Stub) {
+  DefaultSession().RouteFor(t, method, pattern, stub)
+}
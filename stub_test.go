@@ -0,0 +1,92 @@
+package webdriver
+
+import (
+  "errors"
+  "io/ioutil"
+  "net/http"
+  "strings"
+  "testing"
+  "time"
+)
+
+func get(client *http.Client, method, url string) (int, string, error) {
+  req, _ := http.NewRequest(method, url, strings.NewReader(""))
+  resp, err := client.Do(req)
+  if err != nil {
+    return 0, "", err
+  }
+  defer resp.Body.Close()
+  body, _ := ioutil.ReadAll(resp.Body)
+  return resp.StatusCode, string(body), nil
+}
+
+func Test_Route(t *testing.T) {
+  p, client := startProxy(t)
+  srv := backend(t, true)
+
+  remove, err := p.Route("POST", "*/api/verify*", JSONStub(400, `{"Message":"Verification failed"}`))
+  if err != nil {
+    t.Fatalf("Cannot add a route: %s", err)
+  }
+
+  status, body, err := get(client, "POST", srv.URL+"/api/verify?token=x")
+  if err != nil || status != 400 || body != `{"Message":"Verification failed"}` {
+    t.Errorf("Expected the canned answer, got %d %q %v", status, body, err)
+  }
+  if status, _, _ = get(client, "GET", srv.URL+"/api/verify"); status != 404 {
+    t.Errorf("Expected a GET to reach the server, got %d", status)
+  }
+
+  entries := p.Entries()
+  if len(entries) != 2 || entries[0].Comment != "stubbed" || entries[0].Response.Status != 400 || entries[1].Comment != "" {
+    t.Errorf("Expected the stubbed request to be recorded as such, got %+v", entries)
+  }
+
+  p.Route("*", srv.URL+"/api/*", Stub{Status: 503})
+  if status, body, _ = get(client, "POST", srv.URL+"/api/verify"); status != 503 || body != "" {
+    t.Errorf("Expected the later route to win, got %d %q", status, body)
+  }
+
+  remove()
+  p.ClearRoutes()
+  if status, _, _ = get(client, "POST", srv.URL+"/api/verify"); status != 404 {
+    t.Errorf("Expected the routes to be gone, got %d", status)
+  }
+}
+
+func Test_Route_delay_abort(t *testing.T) {
+  p, client := startProxy(t)
+  srv := backend(t, false)
+
+  p.Route("", "*/slow", Stub{Delay: 50 * time.Millisecond})
+  start := time.Now()
+  status, _, err := get(client, "GET", srv.URL+"/slow")
+  if err != nil || status != 404 || time.Since(start) < 50*time.Millisecond {
+    t.Errorf("Expected the server's answer after the delay, got %d %v after %s", status, err, time.Since(start))
+  }
+
+  p.Route("", "*/down", Stub{Abort: true})
+  if _, _, err = get(client, "GET", srv.URL+"/down"); err == nil {
+    t.Errorf("Expected the connection to be dropped")
+  }
+}
+
+func Test_RouteFor(t *testing.T) {
+  p, client := startProxy(t)
+  srv := backend(t, false)
+  s := &Session{Proxy: p}
+
+  tb := &recordingTB{TB: t, name: "Test_Verify"}
+  s.RouteFor(tb, "GET", "*/login", Stub{Status: 500})
+  if status, _, _ := get(client, "GET", srv.URL+"/login"); status != 500 {
+    t.Errorf("Expected the stub during the test, got %d", status)
+  }
+  tb.finish()
+  if status, _, _ := get(client, "GET", srv.URL+"/login"); status != 200 {
+    t.Errorf("Expected the stub to be removed after the test, got %d", status)
+  }
+
+  if _, err := (&Session{}).Route("GET", "*", Stub{}); !errors.Is(err, ErrNoProxy) {
+    t.Errorf("Expected ErrNoProxy without a proxy, got %v", err)
+  }
+}
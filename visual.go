@@ -0,0 +1,230 @@
+package webdriver
+
+import (
+  "bytes"
+  "fmt"
+  "image"
+  "image/color"
+  "image/draw"
+  "image/png"
+  "os"
+  "path/filepath"
+)
+
+// CompareOption adjusts how MatchesBaseline compares a screenshot
+type CompareOption func(*compareOptions)
+
+type compareOptions struct {
+  tolerance uint8
+  maxPixels int
+  ignore    []Locator
+  rects     []image.Rectangle
+}
+
+// Tolerance lets each colour channel of a pixel differ from the baseline by
+// up to delta before the pixel counts as changed, which absorbs anti-aliasing noise
+func Tolerance(delta uint8) CompareOption {
+  return func(o *compareOptions) { o.tolerance = delta }
+}
+
+// MaxDiffPixels lets up to n pixels differ before the comparison fails
+func MaxDiffPixels(n int) CompareOption {
+  return func(o *compareOptions) { o.maxPixels = n }
+}
+
+// Ignore leaves out the area covered by the elements found by locs, e.g. a
+// clock or a randomly chosen thumbnail. All matching elements are left out
+func Ignore(locs ...Locator) CompareOption {
+  return func(o *compareOptions) { o.ignore = append(o.ignore, locs...) }
+}
+
+// IgnoreRect leaves out a fixed area of the screenshot, in image pixels
+func IgnoreRect(r image.Rectangle) CompareOption {
+  return func(o *compareOptions) { o.rects = append(o.rects, r) }
+}
+
+// VisualDiffError is returned by MatchesBaseline when the screenshot differs
+// from the baseline. The actual screenshot and a diff image with the changed
+// pixels in red are kept next to the other artifacts
+type VisualDiffError struct {
+  Name     string
+  Pixels   int // how many pixels differ
+  Baseline string
+  Actual   string
+  Diff     string
+}
+
+func (e *VisualDiffError) Error() string {
+  return fmt.Sprintf("Screenshot %s differs from %s in %d pixels, see %s", e.Name, e.Baseline, e.Pixels, e.Diff)
+}
+
+// MatchesBaseline takes a screenshot and compares it with the baseline image
+// name.png in Config.BaselineDir. With Config.UpdateBaselines set
+// (-webdriver.update-baselines) the screenshot becomes the new baseline instead
+func (s *Session) MatchesBaseline(name string, opts ...CompareOption) error {
+  var o compareOptions
+  for _, opt := range opts {
+    opt(&o)
+  }
+
+  data, err := s.Drv.Screenshot()
+  if err != nil {
+    return s.wrap("screenshot", nil, err)
+  }
+  actual, err := png.Decode(bytes.NewReader(data))
+  if err != nil {
+    return fmt.Errorf("Cannot decode screenshot: %w", err)
+  }
+
+  dir := s.Config.BaselineDir
+  if dir == "" {
+    dir = filepath.Join("testdata", "baselines")
+  }
+  baselinePath := filepath.Join(dir, name+".png")
+  if s.Config.UpdateBaselines {
+    if err = os.MkdirAll(filepath.Dir(baselinePath), 0755); err != nil {
+      return err
+    }
+    return writePNG(baselinePath, actual)
+  }
+
+  baseline, err := readPNG(baselinePath)
+  if err != nil {
+    return fmt.Errorf("No usable baseline for %s, run with -webdriver.update-baselines to create it: %w", name, err)
+  }
+
+  ignore := o.rects
+  for _, loc := range o.ignore {
+    rects, err := s.elementRects(loc)
+    if err != nil {
+      return err
+    }
+    ignore = append(ignore, rects...)
+  }
+
+  diff, n := CompareImages(baseline, actual, o.tolerance, ignore)
+  if n <= o.maxPixels {
+    return nil
+  }
+
+  out := filepath.Join(s.artifactDir(), "visual")
+  e := &VisualDiffError{Name: name, Pixels: n, Baseline: baselinePath,
+    Actual: filepath.Join(out, name+".actual.png"), Diff: filepath.Join(out, name+".diff.png")}
+  if err = os.MkdirAll(filepath.Dir(e.Actual), 0755); err == nil {
+    err = writePNG(e.Actual, actual)
+  }
+  if err == nil {
+    err = writePNG(e.Diff, diff)
+  }
+  if err != nil {
+    return fmt.Errorf("%s (and the images could not be kept: %s)", e, err)
+  }
+  return e
+}
+
+// elementRects returns the area of the screenshot covered by each element matching loc
+func (s *Session) elementRects(loc Locator) ([]image.Rectangle, error) {
+  elems, err := s.FindAll(loc)
+  if err != nil {
+    return nil, err
+  }
+  var rects []image.Rectangle
+  for _, e := range elems {
+    r, err := s.elementRect(e, loc, false)
+    if err != nil {
+      return nil, err
+    }
+    rects = append(rects, r)
+  }
+  return rects, nil
+}
+
+var (
+  diffColor   = color.RGBA{0xff, 0, 0, 0xff}
+  ignoreColor = color.RGBA{0, 0x80, 0xff, 0xff}
+)
+
+// CompareImages counts the pixels of got that differ from want by more than
+// tolerance in any channel, outside the ignore rectangles. Pixels outside one
+// image but inside the other all count as different. The diff image shows got
+// faded, with differing pixels in red and ignored areas tinted blue
+func CompareImages(want, got image.Image, tolerance uint8, ignore []image.Rectangle) (diff *image.RGBA, n int) {
+  wb, gb := want.Bounds(), got.Bounds()
+  bounds := image.Rect(0, 0, wb.Dx(), wb.Dy()).Union(image.Rect(0, 0, gb.Dx(), gb.Dy()))
+  diff = image.NewRGBA(bounds)
+  draw.Draw(diff, bounds, image.White, image.Point{}, draw.Src)
+
+  ignored := func(p image.Point) bool {
+    for _, r := range ignore {
+      if p.In(r) {
+        return true
+      }
+    }
+    return false
+  }
+
+  for y := 0; y < bounds.Dy(); y++ {
+    for x := 0; x < bounds.Dx(); x++ {
+      p := image.Pt(x, y)
+      wp, gp := p.Add(wb.Min), p.Add(gb.Min)
+      inW, inG := wp.In(wb), gp.In(gb)
+
+      var g color.RGBA
+      if inG {
+        g = color.RGBAModel.Convert(got.At(gp.X, gp.Y)).(color.RGBA)
+      }
+      switch {
+      case ignored(p):
+        diff.Set(x, y, blend(g, ignoreColor))
+      case inW && inG && similar(color.RGBAModel.Convert(want.At(wp.X, wp.Y)).(color.RGBA), g, tolerance):
+        diff.Set(x, y, blend(g, color.RGBA{0xff, 0xff, 0xff, 0xff}))
+      default:
+        diff.Set(x, y, diffColor)
+        n++
+      }
+    }
+  }
+  return diff, n
+}
+
+func similar(a, b color.RGBA, tolerance uint8) bool {
+  near := func(x, y uint8) bool {
+    if x > y {
+      x, y = y, x
+    }
+    return y-x <= tolerance
+  }
+  return near(a.R, b.R) && near(a.G, b.G) && near(a.B, b.B) && near(a.A, b.A)
+}
+
+// blend moves c three quarters of the way towards tint, leaving the page faintly visible
+func blend(c, tint color.RGBA) color.RGBA {
+  mix := func(a, b uint8) uint8 { return uint8((int(a) + 3*int(b)) / 4) }
+  return color.RGBA{mix(c.R, tint.R), mix(c.G, tint.G), mix(c.B, tint.B), 0xff}
+}
+
+func readPNG(path string) (image.Image, error) {
+  f, err := os.Open(path)
+  if err != nil {
+    return nil, err
+  }
+  defer f.Close()
+  return png.Decode(f)
+}
+
+func writePNG(path string, img image.Image) error {
+  f, err := os.Create(path)
+  if err != nil {
+    return err
+  }
+  if err = png.Encode(f, img); err != nil {
+    f.Close()
+    return err
+  }
+  return f.Close()
+}
+
+// MatchesBaseline compares a screenshot of the default session with its baseline
+func MatchesBaseline(name string, opts ...CompareOption) error {
+  return DefaultSession().MatchesBaseline(name, opts...)
+}
@@ -0,0 +1,84 @@
+package webdriver
+
+import (
+  "code.grantmurray.com/webdriver/fakewd"
+  "errors"
+  "image"
+  "image/color"
+  "os"
+  "testing"
+)
+
+func Test_CompareImages(t *testing.T) {
+  white := color.RGBA{0xff, 0xff, 0xff, 0xff}
+  want := fakewd.Solid(10, 10, white)
+  got := fakewd.Solid(10, 10, white)
+  got.Set(1, 1, color.RGBA{0xfa, 0xff, 0xff, 0xff}) // within tolerance
+  got.Set(2, 2, color.RGBA{0, 0, 0, 0xff})
+  got.Set(8, 8, color.RGBA{0, 0, 0, 0xff}) // ignored
+
+  diff, n := CompareImages(want, got, 8, []image.Rectangle{image.Rect(7, 7, 10, 10)})
+  if n != 1 {
+    t.Errorf("Expected 1 differing pixel, got %d", n)
+  }
+  if c := diff.RGBAAt(2, 2); c != diffColor {
+    t.Errorf("Expected the differing pixel to be marked red, got %v", c)
+  }
+  if c := diff.RGBAAt(1, 1); c == diffColor {
+    t.Errorf("Expected the pixel within tolerance not to be marked")
+  }
+
+  if _, n = CompareImages(want, fakewd.Solid(10, 12, white), 0, nil); n != 20 {
+    t.Errorf("Expected the 20 extra pixels of a taller image to differ, got %d", n)
+  }
+}
+
+func Test_MatchesBaseline(t *testing.T) {
+  s, srv := newFakeSession(t)
+  page := loginPage(srv)
+  page.Find("form").X, page.Find("form").Y = 10, 20
+  page.Find("form").Width, page.Find("form").Height = 100, 50
+  page.Screenshot = fakewd.Solid(200, 100, color.RGBA{0xee, 0xee, 0xee, 0xff})
+  s.Drv.Get(loginURL)
+
+  s.Config.BaselineDir, s.Config.ArtifactDir = t.TempDir(), t.TempDir()
+
+  if err := s.MatchesBaseline("login"); !errors.Is(err, os.ErrNotExist) {
+    t.Errorf("Expected a missing baseline to be reported, got %v", err)
+  }
+
+  s.Config.UpdateBaselines = true
+  if err := s.MatchesBaseline("login"); err != nil {
+    t.Fatalf("Updating the baseline failed: %s", err)
+  }
+  s.Config.UpdateBaselines = false
+  if err := s.MatchesBaseline("login"); err != nil {
+    t.Errorf("Expected a match against the new baseline, got %s", err)
+  }
+
+  // a change inside the form can be ignored, outside it cannot
+  changed := fakewd.Solid(200, 100, color.RGBA{0xee, 0xee, 0xee, 0xff})
+  changed.Set(50, 40, color.Black)
+  page.Screenshot = changed
+  if err := s.MatchesBaseline("login", Ignore(CSS("form"))); err != nil {
+    t.Errorf("Expected the change inside the form to be ignored, got %s", err)
+  }
+
+  changed.Set(150, 90, color.Black)
+  err := s.MatchesBaseline("login", Ignore(CSS("form")))
+  var verr *VisualDiffError
+  if !errors.As(err, &verr) {
+    t.Fatalf("Expected a *VisualDiffError, got %v", err)
+  }
+  if verr.Pixels != 1 {
+    t.Errorf("Expected 1 differing pixel, got %d", verr.Pixels)
+  }
+  for _, p := range []string{verr.Actual, verr.Diff} {
+    if _, err := readPNG(p); err != nil {
+      t.Errorf("Expected %s to be written: %s", p, err)
+    }
+  }
+  if err = s.MatchesBaseline("login", MaxDiffPixels(2)); err != nil {
+    t.Errorf("Expected 2 changed pixels to be allowed, got %s", err)
+  }
+}
@@ -0,0 +1,115 @@
+package webdriver
+
+import (
+  "context"
+  "fmt"
+  "time"
+)
+
+// wait defaults for new sessions
+const (
+  DefaultWaitTimeout     = 5 * time.Second
+  DefaultPollInterval    = 100 * time.Millisecond
+  DefaultMaxPollInterval = time.Second
+)
+
+// Condition is something WaitUntil can poll. Check reports whether waiting is
+// over and describes what it observed, the observation ends up in the timeout
+// error. An error from Check stops the wait immediately. String describes
+// what is being waited for, see conditions.go for the ready made ones
+type Condition interface {
+  Check(s *Session) (done bool, observed string, err error)
+  String() string
+}
+
+// ConditionFunc lets an ordinary function be used as a Condition, use Named
+// to give it a better description than the default
+type ConditionFunc func(s *Session) (done bool, observed string, err error)
+
+// Check calls f
+func (f ConditionFunc) Check(s *Session) (bool, string, error) {
+  return f(s)
+}
+
+func (f ConditionFunc) String() string {
+  return "custom condition"
+}
+
+// TimeoutError is returned by WaitUntil when the condition was still false at the deadline
+type TimeoutError struct {
+  Condition string
+  Observed  string // what the condition saw the last time it was checked
+  Waited    time.Duration
+  Err       error // the context's error
+}
+
+func (e *TimeoutError) Error() string {
+  return fmt.Sprintf("Timed out after %s waiting for %s, last saw: %s", e.Waited.Round(time.Millisecond), e.Condition, e.Observed)
+}
+
+func (e *TimeoutError) Unwrap() error {
+  return e.Err
+}
+
+// WaitUntil polls cond until it is done, returning nil, or until ctx is done.
+// When ctx has no deadline s.WaitTimeout is applied. The condition is checked
+// straight away, after that every s.PollInterval, growing by s.PollBackoff
+// each time up to s.MaxPollInterval
+func (s *Session) WaitUntil(ctx context.Context, cond Condition) error {
+  s.waiting++
+  defer func() { s.waiting-- }()
+
+  if _, ok := ctx.Deadline(); !ok {
+    var cancel context.CancelFunc
+    ctx, cancel = context.WithTimeout(ctx, s.WaitTimeout)
+    defer cancel()
+  }
+
+  start := time.Now()
+  interval := s.PollInterval
+  if interval <= 0 {
+    interval = DefaultPollInterval
+  }
+
+  for {
+    done, observed, err := cond.Check(s)
+    if err != nil {
+      return fmt.Errorf("Error while waiting for %s: %w", cond, err)
+    }
+    if done {
+      return nil
+    }
+
+    timer := time.NewTimer(interval)
+    select {
+    case <-ctx.Done():
+      timer.Stop()
+      if ctx.Err() == context.Canceled {
+        return fmt.Errorf("Waiting for %s: %w", cond, ctx.Err())
+      }
+      return &TimeoutError{Condition: cond.String(), Observed: observed, Waited: time.Since(start), Err: ctx.Err()}
+    case <-timer.C:
+    }
+
+    if s.PollBackoff > 1 {
+      interval = time.Duration(float64(interval) * s.PollBackoff)
+      if s.MaxPollInterval > 0 && interval > s.MaxPollInterval {
+        interval = s.MaxPollInterval
+      }
+    }
+  }
+}
+
+// sync waits for s.Sync. Inside WaitUntil it does nothing: the condition
+// being polled is retried anyway, and s.Sync itself may find elements
+func (s *Session) sync() error {
+  if s.Sync == nil || s.waiting > 0 {
+    return nil
+  }
+  return s.WaitUntil(context.Background(), s.Sync)
+}
+
+// WaitUntil polls cond on the default session, see Session.WaitUntil
+func WaitUntil(ctx context.Context, cond Condition) error {
+  return DefaultSession().WaitUntil(ctx, cond)
+}
@@ -0,0 +1,104 @@
+package webdriver
+
+import (
+  "context"
+  "errors"
+  "fmt"
+  "strings"
+  "testing"
+  "time"
+)
+
+// counter is a Condition that becomes done on the n-th check
+type counter struct {
+  n, checks int
+}
+
+func (c *counter) Check(s *Session) (bool, string, error) {
+  c.checks++
+  return c.checks >= c.n, fmt.Sprintf("%d checks", c.checks), nil
+}
+
+func (c *counter) String() string { return fmt.Sprintf("%d checks", c.n) }
+
+func Test_WaitUntil_already_true(t *testing.T) {
+  s := NewSession(nil)
+  s.PollInterval = time.Hour
+
+  start := time.Now()
+  if err := s.WaitUntil(context.Background(), &counter{n: 1}); err != nil {
+    t.Fatalf("WaitUntil failed: %s", err)
+  }
+  if d := time.Since(start); d > 50*time.Millisecond {
+    t.Errorf("WaitUntil slept %s for a condition that was already true", d)
+  }
+}
+
+func Test_WaitUntil_polls(t *testing.T) {
+  s := NewSession(nil)
+  s.PollInterval = time.Millisecond
+
+  c := &counter{n: 4}
+  if err := s.WaitUntil(context.Background(), c); err != nil {
+    t.Fatalf("WaitUntil failed: %s", err)
+  }
+  if c.checks != 4 {
+    t.Errorf("Expected 4 checks, got %d", c.checks)
+  }
+}
+
+func Test_WaitUntil_timeout(t *testing.T) {
+  s := NewSession(nil)
+  s.PollInterval = time.Millisecond
+  s.PollBackoff = 2
+  s.MaxPollInterval = 8 * time.Millisecond
+
+  ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+  defer cancel()
+
+  err := s.WaitUntil(ctx, &counter{n: 1000})
+  var te *TimeoutError
+  if !errors.As(err, &te) {
+    t.Fatalf("Expected a TimeoutError, got %v", err)
+  }
+  if !errors.Is(err, context.DeadlineExceeded) {
+    t.Errorf("Expected the TimeoutError to wrap context.DeadlineExceeded")
+  }
+  if te.Condition != "1000 checks" || !strings.HasSuffix(te.Observed, " checks") {
+    t.Errorf("Timeout error lacks detail: %s", err)
+  }
+}
+
+func Test_WaitUntil_default_timeout(t *testing.T) {
+  s := NewSession(nil)
+  s.PollInterval = time.Millisecond
+  s.WaitTimeout = 20 * time.Millisecond
+
+  var te *TimeoutError
+  if err := s.WaitUntil(context.Background(), &counter{n: 1000}); !errors.As(err, &te) {
+    t.Fatalf("Expected a TimeoutError, got %v", err)
+  }
+}
+
+func Test_WaitUntil_canceled(t *testing.T) {
+  s := NewSession(nil)
+  ctx, cancel := context.WithCancel(context.Background())
+  cancel()
+
+  err := s.WaitUntil(ctx, &counter{n: 1000})
+  if !errors.Is(err, context.Canceled) {
+    t.Fatalf("Expected context.Canceled, got %v", err)
+  }
+}
+
+func Test_WaitUntil_error(t *testing.T) {
+  s := NewSession(nil)
+  boom := errors.New("boom")
+
+  err := s.WaitUntil(context.Background(), ConditionFunc(func(*Session) (bool, string, error) {
+    return false, "", boom
+  }))
+  if !errors.Is(err, boom) {
+    t.Fatalf("Expected the condition's error, got %v", err)
+  }
+}
@@ -0,0 +1,167 @@
+package webdriver
+
+import (
+  "code.grantmurray.com/webdriver/fakewd"
+  "image/color"
+  "image/png"
+  "os"
+  "path/filepath"
+  "testing"
+  "time"
+)
+
+// newFakeSession connects a Session to a fresh fakewd server
+func newFakeSession(t *testing.T) (*Session, *fakewd.Server) {
+  srv := fakewd.NewServer()
+  s, err := NewRemoteSession(WithURL(srv.URL))
+  if err != nil {
+    srv.Close()
+    t.Fatalf("Cannot connect to fake server: %s", err)
+  }
+  s.InitialWait = 10 * time.Millisecond
+  t.Cleanup(func() {
+    s.Quit()
+    srv.Close()
+  })
+  return s, srv
+}
+
+const loginURL = "https://plog.org:8004/#/login"
+
+func loginPage(srv *fakewd.Server) *fakewd.Page {
+  return srv.Page(loginURL).Add(
+    fakewd.E("form", "name", "loginForm").Add(
+      fakewd.E("input", "name", "UserIdentifier"),
+      fakewd.E("input", "name", "ClearPassword", "type", "password"),
+      fakewd.E("button", "name", "LoginButton").WithText("Login"),
+    ),
+    fakewd.E("p", "name", "LoginMessage").WithText("Authentication failed"),
+  )
+}
+
+func Test_FindNamedElements(t *testing.T) {
+  s, srv := newFakeSession(t)
+  loginPage(srv)
+  s.Drv.Get(loginURL)
+
+  elements, err := s.FindNamedElements([]string{"UserIdentifier", "ClearPassword", "LoginButton"})
+  if err != nil {
+    t.Fatalf("FindNamedElements failed: %s", err)
+  }
+  elements["UserIdentifier"].SendKeys("Selenium-One")
+
+  if v := srv.Page(loginURL).Find(`[name="UserIdentifier"]`).Value(); v != "Selenium-One" {
+    t.Errorf("Expected typed value to reach the page, got %q", v)
+  }
+
+  if _, err = s.FindNamedElements([]string{"NoSuchInput"}); err == nil {
+    t.Errorf("Expected an error for a missing element")
+  }
+}
+
+func Test_FetchText(t *testing.T) {
+  s, srv := newFakeSession(t)
+  loginPage(srv)
+  s.Drv.Get(loginURL)
+
+  msg, err := s.FetchText("p[name='LoginMessage']")
+  if err != nil {
+    t.Fatalf("FetchText failed: %s", err)
+  }
+  if msg != "Authentication failed" {
+    t.Errorf("Expected \"Authentication failed\", got \"%s\"", msg)
+  }
+}
+
+func Test_WaitFor(t *testing.T) {
+  s, srv := newFakeSession(t)
+  loginPage(srv)
+  s.Drv.Get(loginURL)
+
+  s.WaitFor(200*time.Millisecond, s.ElementToAppear, "form[name=\"loginForm\"]")
+  if s.WaitForTimedOut {
+    t.Errorf("Expected loginForm to appear")
+  }
+
+  s.WaitFor(200*time.Millisecond, s.UrlIsCurrent, "https://plog.org:8004/#/album")
+  if !s.WaitForTimedOut {
+    t.Errorf("Expected to time out waiting for the album url")
+  }
+  if !s.UrlIsCurrent([]interface{}{42, loginURL}) {
+    t.Errorf("UrlIsCurrent should skip a non-string argument rather than panic")
+  }
+}
+
+func Test_ScreenshotToFile(t *testing.T) {
+  s, srv := newFakeSession(t)
+  srv.Page(loginURL).Screenshot = fakewd.Solid(40, 30, color.RGBA{0, 0, 255, 255})
+  s.Drv.Get(loginURL)
+
+  filename := filepath.Join(t.TempDir(), "shot.png")
+  if err := s.ScreenshotToFile(filename); err != nil {
+    t.Fatalf("ScreenshotToFile failed: %s", err)
+  }
+
+  f, err := os.Open(filename)
+  if err != nil {
+    t.Fatalf("Screenshot not written: %s", err)
+  }
+  defer f.Close()
+  img, err := png.Decode(f)
+  if err != nil {
+    t.Fatalf("Screenshot is not a png: %s", err)
+  }
+  if b := img.Bounds(); b.Dx() != 40 || b.Dy() != 30 {
+    t.Errorf("Expected a 40x30 screenshot, got %v", b)
+  }
+}
+
+func Test_package_functions_use_Drv(t *testing.T) {
+  s, srv := newFakeSession(t)
+  loginPage(srv)
+  s.Drv.Get(loginURL)
+
+  saved := Drv
+  Drv = s.Drv
+  defer func() { Drv = saved }()
+
+  msg, err := FetchText("p[name='LoginMessage']")
+  if err != nil || msg != "Authentication failed" {
+    t.Errorf("Package FetchText did not go through Drv: %q %v", msg, err)
+  }
+}
+
+func Test_fakewd_callbacks_use_the_server(t *testing.T) {
+  s, srv := newFakeSession(t)
+  page := loginPage(srv)
+  s.Drv.Get(loginURL)
+
+  page.Find(`[name="LoginButton"]`).OnClick = func(b *fakewd.Browser) error {
+    srv.Page(loginURL).Add(fakewd.E("p", "name", "Clicked"))
+    srv.HandleScript("return 42", func(b *fakewd.Browser, args []interface{}) (interface{}, error) {
+      return len(srv.Sessions()), nil
+    })
+    return nil
+  }
+
+  done := make(chan error, 1)
+  go func() {
+    err := s.NewElement(Name("LoginButton")).Click()
+    if err == nil {
+      var n int
+      err = s.script("return 42", nil, &n)
+    }
+    done <- err
+  }()
+  select {
+  case err := <-done:
+    if err != nil {
+      t.Errorf("Callback calling back into the server failed: %s", err)
+    }
+  case <-time.After(2 * time.Second):
+    t.Fatalf("Callback calling back into the server deadlocked")
+  }
+  if !s.ElementToAppear([]interface{}{Name("Clicked")}) {
+    t.Errorf("Expected the element added by the callback")
+  }
+}
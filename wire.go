@@ -0,0 +1,159 @@
+package webdriver
+
+import (
+  "bytes"
+  "encoding/json"
+  "errors"
+  "fmt"
+  "github.com/sourcegraph/go-selenium"
+  "io/ioutil"
+  "net/http"
+  "reflect"
+  "strings"
+)
+
+// ErrNoServerURL is returned by the commands webdriver sends itself when the
+// session does not know its server, e.g. one made with NewSession
+var ErrNoServerURL = errors.New("session has no server URL")
+
+// sessionID finds the id of the server side session behind s.Drv, selenium
+// does not hand it out itself
+func (s *Session) sessionID() (string, error) {
+  if s.id != "" {
+    return s.id, nil
+  }
+  if s.id = driverSessionID(s.Drv); s.id != "" {
+    return s.id, nil
+  }
+  caps, err := s.Drv.Capabilities()
+  if err != nil {
+    return "", s.wrap("retrieve capabilities", nil, err)
+  }
+  if id, ok := caps["webdriver.remote.sessionid"].(string); ok && id != "" {
+    s.id = id
+    return id, nil
+  }
+  return "", fmt.Errorf("Cannot tell the id of the session on %s, the driver does not report it", s.Config.URL)
+}
+
+// driverSessionID returns the id the new session request gave the driver, if
+// it is one of selenium's remote drivers
+func driverSessionID(drv selenium.WebDriver) string {
+  return hiddenID(drv)
+}
+
+// hiddenID reads the server side id selenium keeps in an unexported field of
+// its remote drivers and elements, "" for anything else
+func hiddenID(v interface{}) string {
+  rv := reflect.ValueOf(v)
+  if rv.Kind() == reflect.Ptr {
+    rv = rv.Elem()
+  }
+  if rv.Kind() != reflect.Struct {
+    return ""
+  }
+  if f := rv.FieldByName("id"); f.IsValid() && f.Kind() == reflect.String {
+    return f.String()
+  }
+  return ""
+}
+
+// command sends a session command selenium has no method for straight to the
+// server, e.g. command("POST", "/log", ...) goes to /session/<id>/log.
+// The reply's value is decoded into result when it is not nil
+func (s *Session) command(method, path string, params, result interface{}) error {
+  id, err := s.sessionID()
+  if err != nil {
+    return err
+  }
+  return s.send(method, "/session/"+id+path, params, result)
+}
+
+// script runs src and converts its result into result, which must be a
+// pointer to something the result can be decoded into as JSON
+func (s *Session) script(src string, args []interface{}, result interface{}) error {
+  res, err := s.Drv.ExecuteScript(src, args)
+  if err != nil {
+    return s.wrap("execute script", nil, err)
+  }
+  data, err := json.Marshal(res)
+  if err != nil {
+    return err
+  }
+  if err = json.Unmarshal(data, result); err != nil {
+    return fmt.Errorf("Unexpected script result %s: %w", data, err)
+  }
+  return nil
+}
+
+// wireReply covers both JSON wire protocol replies, which carry a status,
+// and W3C replies, which put an error name in the value
+type wireReply struct {
+  Status int             `json:"status"`
+  Value  json.RawMessage `json:"value"`
+}
+
+type wireError struct {
+  Error   string `json:"error"`
+  Message string `json:"message"`
+}
+
+func (s *Session) send(method, path string, params, result interface{}) error {
+  op := method + " " + path
+  if s.Config.URL == "" {
+    return &Error{Op: op, Err: fmt.Errorf("%w, set Config.URL to the server the driver talks to", ErrNoServerURL)}
+  }
+
+  var body *bytes.Reader
+  if params != nil {
+    data, err := json.Marshal(params)
+    if err != nil {
+      return err
+    }
+    body = bytes.NewReader(data)
+  } else {
+    body = bytes.NewReader(nil)
+  }
+  req, err := http.NewRequest(method, strings.TrimSuffix(s.Config.URL, "/")+path, body)
+  if err != nil {
+    return err
+  }
+  req.Header.Set("Content-Type", "application/json;charset=UTF-8")
+  req.Header.Set("Accept", "application/json")
+
+  resp, err := http.DefaultClient.Do(req)
+  if err != nil {
+    return s.wrap(op, nil, err)
+  }
+  defer resp.Body.Close()
+  data, err := ioutil.ReadAll(resp.Body)
+  if err != nil {
+    return s.wrap(op, nil, err)
+  }
+
+  var r wireReply
+  if err = json.Unmarshal(data, &r); err != nil {
+    return s.wrap(op, nil, fmt.Errorf("%s: %.100q", resp.Status, data))
+  }
+
+  var we wireError
+  json.Unmarshal(r.Value, &we)
+  switch {
+  case r.Status != 0:
+    msg := we.Message
+    if msg == "" {
+      msg = fmt.Sprintf("unknown error - %d", r.Status)
+    }
+    return &Error{Op: op, Kind: statusErrors[r.Status], Err: errors.New(msg)}
+  case we.Error != "" || resp.StatusCode >= 400:
+    if we.Error == "" {
+      we.Error = resp.Status
+    }
+    return &Error{Op: op, Kind: messageErrors[we.Error], Err: fmt.Errorf("%s: %s", we.Error, we.Message)}
+  }
+
+  if result == nil || len(r.Value) == 0 {
+    return nil
+  }
+  return json.Unmarshal(r.Value, result)
+}